@@ -1,6 +1,7 @@
 package cmd
 
 import (
+    "context"
     "fmt"
     "os"
     "os/exec"
@@ -29,9 +30,14 @@ var buildCmd = &cobra.Command{
     Use:   "build",
     Short: "Build prompt with variable substitution and generate final_prompt.md",
     RunE: func(cmd *cobra.Command, args []string) error {
+        // Refuse to package credentials or personal data
+        if err := requireNoSecrets("build", packager.ManifestFile, packager.BundleFiles()); err != nil {
+            return err
+        }
+        
         // If no flags provided, use legacy build
         if len(varFlags) == 0 && toolFlag == "" {
-            art, err := packager.Build()
+            art, err := packager.Build(cmd.Context())
             if err != nil {
                 return err
             }
@@ -40,27 +46,27 @@ var buildCmd = &cobra.Command{
         }
         
         // New build with variables
-        if err := packager.BuildWithVariables(varFlags); err != nil {
+        if err := packager.BuildWithVariables(cmd.Context(), varFlags); err != nil {
             return err
         }
         
         // If tool is specified, pipe the prompt to it
         if toolFlag != "" {
-            return runWithTool(toolFlag)
+            return runWithTool(cmd.Context(), toolFlag)
         }
         
         return nil
     },
 }
 
-func runWithTool(toolName string) error {
+func runWithTool(ctx context.Context, toolName string) error {
     adapter, exists := Adapters[toolName]
     if !exists {
         return fmt.Errorf("unsupported tool: %s", toolName)
     }
     
     // Get the expected prompt filename
-    filename, err := packager.GetPromptFilename(packager.ManifestFile)
+    filename, err := packager.GetPromptFilename(ctx, packager.ManifestFile)
     if err != nil {
         return fmt.Errorf("failed to get prompt filename: %w", err)
     }
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/promptbucket/cli/internal/cache"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and clean the local package cache",
+	Long: `Manage the local cache of downloaded manifests and registry details.
+
+pull, fetch, info and HTTP "from:" parents read through the cache in
+~/.promptbucket/cache (or $PROMPTBUCKET_CACHE_DIR). Published versions are
+served from it without asking the registry; other URLs are revalidated. With
+--offline, only the cache is used.`,
+}
+
+var cacheLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List cached entries, most recently used first",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := cache.Default()
+		entries, err := c.Entries()
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Printf("Cache at %s is empty.\n", c.Dir)
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY\tSIZE\tDIGEST\tLAST USED\tKIND")
+		for _, e := range entries {
+			kind := "revalidated"
+			if e.Immutable {
+				kind = "immutable"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s ago\t%s\n", e.Key, formatBytes(e.Size), shortDigest(e.Digest), formatAge(time.Since(e.LastUsed)), kind)
+		}
+		w.Flush()
+
+		size, err := c.Size()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n%d entries, %s on disk in %s\n", len(entries), formatBytes(size), c.Dir)
+		return nil
+	},
+}
+
+var cacheVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check cached content against its digests",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := cache.Default()
+		problems, err := c.Verify()
+		if err != nil {
+			return err
+		}
+		if len(problems) == 0 {
+			fmt.Println("✅ Cache is intact")
+			return nil
+		}
+
+		for _, p := range problems {
+			what := p.Path
+			if p.Key != "" {
+				what = p.Key
+			}
+			fmt.Printf("❌ %s: %s\n", what, p.Message)
+		}
+		fmt.Println("\nRun 'promptbucket cache prune' to remove broken entries.")
+		cmd.SilenceUsage = true
+		return fmt.Errorf("cache has %d problem(s)", len(problems))
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove broken, old and least recently used entries",
+	Long: `Remove broken entries, entries unused for longer than --max-age, and the
+least recently used entries until the cache fits in --max-size.
+
+Pass 0 to either flag to disable that limit.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		maxAgeFlag, _ := cmd.Flags().GetString("max-age")
+		maxSizeFlag, _ := cmd.Flags().GetString("max-size")
+
+		var opts cache.PruneOptions
+		if maxAgeFlag != "0" {
+			maxAge, err := parseAge("--max-age", maxAgeFlag)
+			if err != nil {
+				return err
+			}
+			opts.MaxAge = maxAge
+		}
+		maxSize, err := parseSize("--max-size", maxSizeFlag)
+		if err != nil {
+			return err
+		}
+		opts.MaxSize = maxSize
+
+		c := cache.Default()
+		result, err := c.Prune(opts)
+		if err != nil {
+			return err
+		}
+		if len(result.Removed) == 0 && result.Broken == 0 && result.Freed == 0 {
+			fmt.Println("✅ Nothing to prune")
+			return nil
+		}
+		for _, e := range result.Removed {
+			fmt.Printf("🗑️  %s (last used %s ago)\n", e.Key, formatAge(time.Since(e.LastUsed)))
+		}
+		if result.Broken > 0 {
+			fmt.Printf("🗑️  %d broken entries\n", result.Broken)
+		}
+		fmt.Printf("✅ Pruned %d entries, freed %s\n", len(result.Removed)+result.Broken, formatBytes(result.Freed))
+		return nil
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Delete the whole cache",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := cache.Default()
+		freed, err := c.Clear()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Cleared %s (freed %s)\n", c.Dir, formatBytes(freed))
+		return nil
+	},
+}
+
+var sizeRe = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([KMG]i?B?|B)?$`)
+
+// parseSize parses the value of a size flag such as "500KB", "100MB" or
+// "1GiB". Units are powers of 1024; "0" means no limit.
+func parseSize(flag, s string) (int64, error) {
+	m := sizeRe.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(s)))
+	if m == nil {
+		return 0, fmt.Errorf("invalid %s %q (expected e.g. 500KB, 100MB or 1GB)", flag, s)
+	}
+	n, _ := strconv.ParseFloat(m[1], 64)
+	switch strings.TrimSuffix(strings.TrimSuffix(m[2], "B"), "I") {
+	case "K":
+		n *= 1 << 10
+	case "M":
+		n *= 1 << 20
+	case "G":
+		n *= 1 << 30
+	}
+	return int64(n), nil
+}
+
+// formatBytes formats a size with a binary unit.
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// formatAge formats a duration to its largest whole unit.
+func formatAge(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	}
+	return fmt.Sprintf("%ds", int(d/time.Second))
+}
+
+// shortDigest abbreviates a "sha256:<hex>" digest for tables.
+func shortDigest(digest string) string {
+	if len(digest) > 19 {
+		return digest[:19]
+	}
+	return digest
+}
+
+func init() {
+	cachePruneCmd.Flags().String("max-age", formatAge(cache.DefaultMaxAge), "Remove entries not used for this long (e.g. 7d, 2w, 12h)")
+	cachePruneCmd.Flags().String("max-size", fmt.Sprintf("%dMB", cache.DefaultMaxSize>>20), "Keep the cache under this size (e.g. 100MB)")
+	cacheCmd.AddCommand(cacheLsCmd, cacheVerifyCmd, cachePruneCmd, cacheClearCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/promptbucket/cli/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete org/name[:version]",
+	Short: "Delete a package, or one version of it, from the registry",
+	Long: `Permanently delete a package and all of its versions, or a single version
+with org/name:version. Anyone depending on what is deleted will no longer be
+able to pull or install it; consider 'promptbucket yank' or
+'promptbucket deprecate' instead.
+
+You are asked to type what is being deleted to confirm. Pass --yes to skip the
+prompt, e.g. in scripts.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		ref, err := parsePackageRef(args[0])
+		if err != nil {
+			return err
+		}
+		if ref.Constraint != "" || ref.DistTag != "" {
+			return fmt.Errorf("delete takes an exact version, e.g. %s/%s:1.0.0", ref.Org, ref.Name)
+		}
+		name := ref.Org + "/" + ref.Name
+
+		client, err := ref.client()
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+
+		// Show what will be deleted
+		pkg, err := client.Package(cmd.Context(), ref.Org, ref.Name)
+		if registry.IsNotFound(err) {
+			return fmt.Errorf("package not found: %s", name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get package info: %w", err)
+		}
+		if ref.Version != "" {
+			found := false
+			for _, v := range pkg.Versions {
+				found = found || v.Tag == ref.Version
+			}
+			if !found {
+				return fmt.Errorf("version not found: %s", ref)
+			}
+			fmt.Printf("🗑️  About to delete %s\n", ref)
+		} else {
+			fmt.Printf("🗑️  About to delete %s and all %d version(s)", name, len(pkg.Versions))
+			if label := visibilityLabel(pkg.Visibility); label != "" {
+				fmt.Printf(" (%s, 📥 %d pulls)", label, pkg.PullCount)
+			}
+			fmt.Println()
+		}
+
+		if !yes {
+			ok, err := confirmByTyping(ref.String())
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("aborted: confirmation did not match %s", ref)
+			}
+		}
+
+		// Delete
+		if ref.Version != "" {
+			err = client.DeleteVersion(cmd.Context(), ref.Org, ref.Name, ref.Version)
+		} else {
+			err = client.Delete(cmd.Context(), ref.Org, ref.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete %s: %w", ref, err)
+		}
+		fmt.Printf("✅ Deleted %s\n", ref)
+		return nil
+	},
+}
+
+// confirmByTyping asks the user to type name and reports whether they did.
+// It fails rather than prompting when stdin is not a terminal.
+func confirmByTyping(name string) (bool, error) {
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false, fmt.Errorf("refusing to delete without confirmation; pass --yes when not running interactively")
+	}
+	fmt.Printf("   This cannot be undone. Type %s to confirm: ", name)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	return strings.TrimSpace(answer) == name, nil
+}
+
+func init() {
+	deleteCmd.Flags().BoolP("yes", "y", false, "Delete without asking for confirmation")
+	rootCmd.AddCommand(deleteCmd)
+}
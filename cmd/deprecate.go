@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/promptbucket/cli/internal/registry"
+	"github.com/promptbucket/cli/internal/semver"
+	"github.com/spf13/cobra"
+)
+
+var deprecateCmd = &cobra.Command{
+	Use:   "deprecate org/name@range --message text",
+	Short: "Deprecate published versions of a package",
+	Long: `Mark every published version in a range as deprecated. pull, install and
+info show the message to anyone using those versions, but they still resolve.
+
+Versions can be given as org/name:version, org/name:@tag or as a range such as
+org/name@<1.2.0 or "org/name@>=1.0 <1.4"; use org/name@* for every version,
+including pre-releases. As in pull, other ranges only match pre-releases when
+they name one, and the pre-releases they skip are listed. Pass --undo to remove
+the deprecation.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		message, _ := cmd.Flags().GetString("message")
+		undo, _ := cmd.Flags().GetBool("undo")
+		if undo && message != "" {
+			return fmt.Errorf("--message and --undo cannot be used together")
+		}
+		if !undo && message == "" {
+			return fmt.Errorf("--message is required (or --undo to remove a deprecation)")
+		}
+
+		ref, err := parsePackageRef(args[0])
+		if err != nil {
+			return err
+		}
+		if ref.Version == "" && ref.Constraint == "" && ref.DistTag == "" {
+			return fmt.Errorf("specify versions to deprecate, e.g. %s@<1.2.0, %s:1.1.0 or %s@*", ref, ref, ref)
+		}
+
+		client, err := ref.client()
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+
+		versions, err := matchingVersions(cmd.Context(), client, ref)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range versions {
+			if undo {
+				if err := client.Deprecate(cmd.Context(), ref.Org, ref.Name, v.Tag, ""); err != nil {
+					return fmt.Errorf("failed to undeprecate %s/%s:%s: %w", ref.Org, ref.Name, v.Tag, err)
+				}
+				fmt.Printf("✅ %s/%s:%s is no longer deprecated\n", ref.Org, ref.Name, v.Tag)
+				continue
+			}
+			if err := client.Deprecate(cmd.Context(), ref.Org, ref.Name, v.Tag, message); err != nil {
+				return fmt.Errorf("failed to deprecate %s/%s:%s: %w", ref.Org, ref.Name, v.Tag, err)
+			}
+			fmt.Printf("✅ Deprecated %s/%s:%s\n", ref.Org, ref.Name, v.Tag)
+		}
+		return nil
+	},
+}
+
+var yankCmd = &cobra.Command{
+	Use:   "yank org/name:version",
+	Short: "Yank a published version so ranges no longer resolve to it",
+	Long: `Yank a published version. Yanked versions are skipped when resolving latest
+and ranges in pull, install and info, but remain downloadable by their exact
+version so existing lockfiles and pins keep working. Pass --undo to restore
+it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		undo, _ := cmd.Flags().GetBool("undo")
+
+		ref, err := parsePackageRef(args[0])
+		if err != nil {
+			return err
+		}
+		if ref.Version == "" {
+			return fmt.Errorf("yank needs an exact version, e.g. %s/%s:1.2.0", ref.Org, ref.Name)
+		}
+
+		client, err := ref.client()
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+
+		if undo {
+			if err := client.Unyank(cmd.Context(), ref.Org, ref.Name, ref.Version); err != nil {
+				return fmt.Errorf("failed to unyank %s: %w", ref, err)
+			}
+			fmt.Printf("✅ Restored %s\n", ref)
+			return nil
+		}
+		if err := client.Yank(cmd.Context(), ref.Org, ref.Name, ref.Version); err != nil {
+			if registry.IsNotFound(err) {
+				return fmt.Errorf("version not found: %s", ref)
+			}
+			return fmt.Errorf("failed to yank %s: %w", ref, err)
+		}
+		fmt.Printf("✅ Yanked %s\n", ref)
+		fmt.Printf("   Ranges will skip it; pins to %s keep working.\n", ref.Version)
+		return nil
+	},
+}
+
+// matchingVersions returns the published versions a reference selects: the
+// exact version, the version its dist-tag points at, or every version in its
+// range. The range "*" selects pre-releases too; other ranges report the
+// pre-releases they skip.
+func matchingVersions(ctx context.Context, client *registry.Client, ref packageRef) ([]registry.Version, error) {
+	pkg, err := client.Package(ctx, ref.Org, ref.Name)
+	if registry.IsNotFound(err) {
+		return nil, fmt.Errorf("package not found: %s/%s", ref.Org, ref.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get package info: %w", err)
+	}
+
+	var versions []registry.Version
+	if ref.DistTag != "" {
+		v, err := registry.ResolveTag(pkg, ref.DistTag)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	} else if ref.Version != "" {
+		for _, v := range pkg.Versions {
+			if v.Tag == ref.Version {
+				versions = append(versions, v)
+			}
+		}
+	} else {
+		r, err := semver.ParseRange(ref.Constraint)
+		if err != nil {
+			return nil, err
+		}
+		all := ref.Constraint == "*"
+		var skipped []string
+		for _, v := range pkg.Versions {
+			sv, err := semver.Parse(v.Tag)
+			if err != nil {
+				continue
+			}
+			release := sv
+			release.Prerelease = nil
+			switch {
+			case r.Contains(sv) || (all && sv.IsPrerelease()):
+				versions = append(versions, v)
+			case sv.IsPrerelease() && r.Contains(release):
+				skipped = append(skipped, v.Tag)
+			}
+		}
+		if len(skipped) > 0 {
+			fmt.Printf("ℹ️  Skipping pre-release(s) %s; name one in the range or use @* to include them\n", strings.Join(skipped, ", "))
+		}
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no published version matches %s", ref)
+	}
+	return versions, nil
+}
+
+// warnVersionStatus prints a warning if a version being used is deprecated
+// or yanked.
+func warnVersionStatus(name string, v registry.Version) {
+	if v.Yanked {
+		fmt.Printf("⚠️  %s:%s has been yanked by its publisher; it is used only because it was pinned exactly\n", name, v.Tag)
+	}
+	if v.Deprecated != "" {
+		fmt.Printf("⚠️  %s:%s is deprecated: %s\n", name, v.Tag, v.Deprecated)
+	}
+}
+
+func init() {
+	deprecateCmd.Flags().StringP("message", "m", "", "Why the versions are deprecated and what to use instead")
+	deprecateCmd.Flags().Bool("undo", false, "Remove the deprecation")
+	yankCmd.Flags().Bool("undo", false, "Restore a yanked version")
+	rootCmd.AddCommand(deprecateCmd)
+	rootCmd.AddCommand(yankCmd)
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+    "context"
     "fmt"
     "os"
     "os/exec"
@@ -17,34 +18,40 @@ var (
 )
 
 var fetchCmd = &cobra.Command{
-    Use:   "fetch [URL]",
+    Use:   "fetch [URL | org/name:version | org/name:@tag]",
     Short: "Fetch YAML from registry and build with variable substitution",
+    Long: `Fetch a manifest and build it with variable substitution.
+
+The manifest can be given as a URL, or as a published package at an exact
+version (org/name:1.2.0) or at the version a dist-tag points at
+(org/name:@stable). Prefix a package with a registry name from the config file
+to fetch it from that registry (internal:acme/reviewer:1.0.0).`,
     Args:  cobra.ExactArgs(1),
     RunE: func(cmd *cobra.Command, args []string) error {
         url := args[0]
         
         // Fetch and build with variables
-        if err := packager.FetchAndBuild(url, fetchVarFlags); err != nil {
+        if err := packager.FetchAndBuild(cmd.Context(), url, fetchVarFlags); err != nil {
             return err
         }
         
         // If tool is specified, pipe the prompt to it
         if fetchToolFlag != "" {
-            return runFetchWithTool(fetchToolFlag)
+            return runFetchWithTool(cmd.Context(), fetchToolFlag)
         }
         
         return nil
     },
 }
 
-func runFetchWithTool(toolName string) error {
+func runFetchWithTool(ctx context.Context, toolName string) error {
     adapter, exists := Adapters[toolName]
     if !exists {
         return fmt.Errorf("unsupported tool: %s", toolName)
     }
     
     // Get the expected prompt filename
-    filename, err := packager.GetPromptFilename(packager.ManifestFile)
+    filename, err := packager.GetPromptFilename(ctx, packager.ManifestFile)
     if err != nil {
         return fmt.Errorf("failed to get prompt filename: %w", err)
     }
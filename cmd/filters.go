@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/promptbucket/cli/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+// addSearchFilterFlags adds the package filters and sort order accepted by
+// registry searches.
+func addSearchFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArray("tag", []string{}, "Only packages with this tag (repeatable; all must match)")
+	cmd.Flags().String("author", "", "Only packages by this author")
+	cmd.Flags().String("org", "", "Only packages owned by this organization")
+	cmd.Flags().String("language", "", "Only packages in this language")
+	cmd.Flags().String("model-hint", "", "Only packages targeting this model")
+	cmd.Flags().String("licence", "", "Only packages under this licence")
+	cmd.Flags().String("sort", "", "Sort results by "+strings.Join(registry.SortOrders, "|")+" (default: relevance)")
+}
+
+// searchRequest builds a search from a query and the filter flags.
+func searchRequest(cmd *cobra.Command, query string) (registry.SearchRequest, error) {
+	req := registry.SearchRequest{Query: query}
+	req.Tags, _ = cmd.Flags().GetStringArray("tag")
+	req.Author, _ = cmd.Flags().GetString("author")
+	req.Org, _ = cmd.Flags().GetString("org")
+	req.Language, _ = cmd.Flags().GetString("language")
+	req.ModelHint, _ = cmd.Flags().GetString("model-hint")
+	req.Licence, _ = cmd.Flags().GetString("licence")
+	req.Sort, _ = cmd.Flags().GetString("sort")
+
+	if req.Sort != "" && !contains(registry.SortOrders, req.Sort) {
+		return req, fmt.Errorf("invalid --sort %q (expected %s)", req.Sort, strings.Join(registry.SortOrders, ", "))
+	}
+	return req, nil
+}
+
+// hasSearchFilters reports whether any filter flag was given.
+func hasSearchFilters(cmd *cobra.Command) bool {
+	for _, name := range []string{"tag", "author", "org", "language", "model-hint", "licence", "sort"} {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
+var ageRe = regexp.MustCompile(`^(\d+)([dw])$`)
+
+// parseAge parses the value of a duration flag such as "7d", "2w" or "36h".
+func parseAge(flag, s string) (time.Duration, error) {
+	if m := ageRe.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		days := n
+		if m[2] == "w" {
+			days = n * 7
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q (expected e.g. 7d, 2w or 12h)", flag, s)
+	}
+	return d, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
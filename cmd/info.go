@@ -1,13 +1,14 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"strings"
-	"time"
 
 	"github.com/promptbucket/cli/internal/auth"
+	"github.com/promptbucket/cli/internal/cache"
+	"github.com/promptbucket/cli/internal/registry"
+	"github.com/promptbucket/cli/internal/semver"
 	"github.com/spf13/cobra"
 )
 
@@ -18,6 +19,7 @@ var infoCmd = &cobra.Command{
 
 Package can be specified as:
   - org/name (e.g., rawte.mayur/Ui-Artist)
+  - registry:org/name (e.g., internal:acme/reviewer)
   - name (will search for it)`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -25,86 +27,57 @@ Package can be specified as:
 
 		// Parse package specification
 		var orgName, packageName string
+		config := auth.NewConfig()
 		if strings.Contains(packageSpec, "/") {
-			parts := strings.SplitN(packageSpec, "/", 2)
-			orgName = parts[0]
-			packageName = parts[1]
+			ref, err := parsePackageRef(packageSpec)
+			if err != nil {
+				return err
+			}
+			orgName, packageName = ref.Org, ref.Name
+			if config, err = ref.config(); err != nil {
+				return err
+			}
 		} else {
 			// If no org specified, we'll need to search for it
 			packageName = packageSpec
 		}
 
-		// Create auth config and API client
-		config := auth.NewConfig()
-		apiClient := auth.NewAPIClient(config)
+		client := registry.NewFromConfig(config)
 
 		// If we have both org and name, get directly
 		if orgName != "" && packageName != "" {
-			return showPackageInfo(apiClient, orgName, packageName)
+			return showPackageInfo(cmd.Context(), config, client, orgName, packageName)
 		}
 
 		// Otherwise, search for the package first
 		fmt.Printf("🔍 Searching for package '%s'...\n", packageName)
 		
-		endpoint := fmt.Sprintf("/packages/search?q=%s&limit=5", packageName)
-		resp, err := apiClient.Get(endpoint)
+		result, err := client.Search(cmd.Context(), registry.SearchRequest{Query: packageName, ListOptions: registry.ListOptions{Limit: 5}})
 		if err != nil {
 			return fmt.Errorf("failed to search for package: %w", err)
 		}
-		defer resp.Body.Close()
 
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("failed to read response: %w", err)
-		}
-
-		if resp.StatusCode != 200 {
-			return fmt.Errorf("search failed with status %d", resp.StatusCode)
-		}
-
-		var searchResp struct {
-			Results []struct {
-				Name string `json:"name"`
-				Org  string `json:"org"`
-				OrgID string `json:"org_id"`
-			} `json:"results"`
-			Total int `json:"total"`
-		}
-		
-		if err := json.Unmarshal(body, &searchResp); err != nil {
-			return fmt.Errorf("failed to parse search results: %w", err)
-		}
-
-		if searchResp.Total == 0 {
+		if result.Total == 0 {
 			return fmt.Errorf("package '%s' not found", packageName)
 		}
 
 		// If multiple matches, ask user to be more specific
-		if searchResp.Total > 1 {
-			fmt.Printf("\nFound %d packages matching '%s':\n", searchResp.Total, packageName)
-			for _, pkg := range searchResp.Results {
-				if pkg.Org != "" {
-					fmt.Printf("  - %s/%s\n", pkg.Org, pkg.Name)
-				} else {
-					fmt.Printf("  - %s\n", pkg.Name)
-				}
+		if result.Total > 1 {
+			fmt.Printf("\nFound %d packages matching '%s':\n", result.Total, packageName)
+			for _, pkg := range result.Results {
+				fmt.Printf("  - %s\n", pkg.FullName())
 			}
 			fmt.Println("\nPlease specify the full package name (org/name)")
 			return nil
 		}
 
 		// Found exactly one match
-		result := searchResp.Results[0]
-		if result.Org == "" {
-			// Try to extract org from org_id if org field is empty
-			result.Org = result.OrgID
-		}
-		
-		return showPackageInfo(apiClient, result.Org, result.Name)
+		match := result.Results[0]
+		return showPackageInfo(cmd.Context(), config, client, match.Org, match.Name)
 	},
 }
 
-func showPackageInfo(apiClient *auth.APIClient, orgName, packageName string) error {
+func showPackageInfo(ctx context.Context, config *auth.Config, client *registry.Client, orgName, packageName string) error {
 	if orgName == "" || packageName == "" {
 		return fmt.Errorf("invalid package specification")
 	}
@@ -112,29 +85,16 @@ func showPackageInfo(apiClient *auth.APIClient, orgName, packageName string) err
 	fmt.Printf("📦 Fetching info for %s/%s...\n\n", orgName, packageName)
 
 	// Get package details
-	endpoint := fmt.Sprintf("/packages/%s/%s", orgName, packageName)
-	resp, err := apiClient.Get(endpoint)
-	if err != nil {
-		return fmt.Errorf("failed to get package info: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode == 404 {
+	pkg, err := client.Package(ctx, orgName, packageName)
+	if registry.IsNotFound(err) {
 		return fmt.Errorf("package %s/%s not found", orgName, packageName)
 	}
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+	if err != nil {
+		return fmt.Errorf("failed to get package info: %w", err)
 	}
 
-	// Parse package info
-	var pkgInfo map[string]interface{}
-	if err := json.Unmarshal(body, &pkgInfo); err != nil {
-		return fmt.Errorf("failed to parse package info: %w", err)
+	if cache.Offline() {
+		fmt.Printf("📴 Offline: showing the details cached when last online\n\n")
 	}
 
 	// Display package information
@@ -142,92 +102,89 @@ func showPackageInfo(apiClient *auth.APIClient, orgName, packageName string) err
 	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
 
 	// Description
-	if desc, ok := pkgInfo["description"].(string); ok && desc != "" {
-		fmt.Printf("📝 Description:\n   %s\n\n", desc)
+	if pkg.Description != "" {
+		fmt.Printf("📝 Description:\n   %s\n\n", pkg.Description)
+	}
+
+	// Visibility
+	if label := visibilityLabel(pkg.Visibility); label != "" {
+		fmt.Printf("👁️  Visibility: %s\n\n", label)
 	}
 
 	// Stats
 	fmt.Printf("📊 Statistics:\n")
-	if starCount, ok := getFloatValue(pkgInfo["star_count"]); ok {
-		fmt.Printf("   ⭐ Stars: %.0f\n", starCount)
-	}
-	if pullCount, ok := getFloatValue(pkgInfo["pull_count"]); ok {
-		fmt.Printf("   📥 Pulls: %.0f\n", pullCount)
-	}
-	if hasStarred, ok := pkgInfo["has_starred"].(bool); ok && hasStarred {
+	fmt.Printf("   ⭐ Stars: %d\n", pkg.StarCount)
+	fmt.Printf("   📥 Pulls: %d\n", pkg.PullCount)
+	if pkg.HasStarred {
 		fmt.Printf("   ✨ You've starred this package\n")
 	}
 	fmt.Println()
 
 	// Versions
-	if versions, ok := pkgInfo["versions"].([]interface{}); ok && len(versions) > 0 {
-		fmt.Printf("📋 Versions (%d):\n", len(versions))
-		for i, v := range versions {
+	if len(pkg.Versions) > 0 {
+		fmt.Printf("📋 Versions (%d):\n", len(pkg.Versions))
+		for i, version := range pkg.Versions {
 			if i >= 5 {
-				fmt.Printf("   ... and %d more\n", len(versions)-5)
+				fmt.Printf("   ... and %d more\n", len(pkg.Versions)-5)
 				break
 			}
-			if version, ok := v.(map[string]interface{}); ok {
-				tag, _ := version["tag"].(string)
-				createdAt, _ := version["created_at"].(string)
-				digest, _ := version["digest"].(string)
-				
-				fmt.Printf("   • %s", tag)
-				if createdAt != "" {
-					if t, err := time.Parse(time.RFC3339, createdAt); err == nil {
-						fmt.Printf(" (published %s)", t.Format("2006-01-02"))
-					}
-				}
-				if digest != "" && len(digest) > 16 {
-					fmt.Printf("\n     Digest: %s...%s", digest[:8], digest[len(digest)-8:])
-				}
-				fmt.Println()
+			
+			fmt.Printf("   • %s", version.Tag)
+			if t, ok := version.Published(); ok {
+				fmt.Printf(" (published %s)", t.Format("2006-01-02"))
+			}
+			if version.Yanked {
+				fmt.Printf(" [yanked]")
+			}
+			if version.Deprecated != "" {
+				fmt.Printf("\n     ⚠️  Deprecated: %s", version.Deprecated)
 			}
+			if digest := version.Digest; len(digest) > 16 {
+				fmt.Printf("\n     Digest: %s...%s", digest[:8], digest[len(digest)-8:])
+			}
+			fmt.Println()
 		}
 		fmt.Println()
 	}
 
-	// Dates
-	if createdAt, ok := pkgInfo["created_at"].(string); ok {
-		if t, err := time.Parse(time.RFC3339, createdAt); err == nil {
-			fmt.Printf("📅 Created: %s\n", t.Format("Jan 2, 2006"))
+	// Dist-tags
+	if len(pkg.DistTags) > 0 {
+		fmt.Printf("🏷️  Dist-tags:\n")
+		for _, tag := range sortedDistTags(pkg.DistTags) {
+			fmt.Printf("   %s → %s\n", tag, pkg.DistTags[tag])
 		}
+		fmt.Println()
 	}
-	if updatedAt, ok := pkgInfo["updated_at"].(string); ok {
-		if t, err := time.Parse(time.RFC3339, updatedAt); err == nil {
-			fmt.Printf("🔄 Updated: %s\n", t.Format("Jan 2, 2006"))
-		}
+
+	// Dates
+	if t, ok := pkg.Created(); ok {
+		fmt.Printf("📅 Created: %s\n", t.Format("Jan 2, 2006"))
+	}
+	if t, ok := pkg.Updated(); ok {
+		fmt.Printf("🔄 Updated: %s\n", t.Format("Jan 2, 2006"))
 	}
 
 	// Usage instructions
 	fmt.Printf("\n📖 Usage:\n")
 	fmt.Printf("   Pull latest:  promptbucket pull %s/%s\n", orgName, packageName)
-	if versions, ok := pkgInfo["versions"].([]interface{}); ok && len(versions) > 0 {
-		if v, ok := versions[0].(map[string]interface{}); ok {
-			if tag, ok := v["tag"].(string); ok {
-				fmt.Printf("   Pull version: promptbucket pull %s/%s:%s\n", orgName, packageName, tag)
-				fmt.Printf("   Fetch YAML:   promptbucket fetch https://api.promptbucket.co/v1/manifests/%s/%s/%s\n", orgName, packageName, tag)
+	if latest, err := registry.Resolve(pkg, ""); err == nil {
+		tag := latest.Tag
+		if v, err := semver.Parse(tag); err == nil {
+			fmt.Printf("   Pull range:   promptbucket pull %s/%s@^%d.%d\n", orgName, packageName, v.Major, v.Minor)
+		}
+		fmt.Printf("   Pull version: promptbucket pull %s/%s:%s\n", orgName, packageName, tag)
+		for _, distTag := range sortedDistTags(pkg.DistTags) {
+			if distTag != registry.LatestTag {
+				fmt.Printf("   Pull tag:     promptbucket pull %s/%s:@%s\n", orgName, packageName, distTag)
+				break
 			}
 		}
+		fmt.Printf("   Fetch YAML:   promptbucket fetch %s\n", config.GetAPIURL("/manifests/"+orgName+"/"+packageName+"/"+tag))
 	}
 
 	return nil
 }
 
-// Helper function to get float value from interface{}
-func getFloatValue(v interface{}) (float64, bool) {
-	switch val := v.(type) {
-	case float64:
-		return val, true
-	case int:
-		return float64(val), true
-	case int64:
-		return float64(val), true
-	default:
-		return 0, false
-	}
-}
-
 func init() {
 	rootCmd.AddCommand(infoCmd)
 }
\ No newline at end of file
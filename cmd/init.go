@@ -158,6 +158,7 @@ func createInteractiveManifest() error {
     
     // Build manifest content
     var manifestBuilder strings.Builder
+    manifestBuilder.WriteString(fmt.Sprintf("schema_version: %d\n", packager.SchemaVersion))
     manifestBuilder.WriteString(fmt.Sprintf("name: %s\n", personaName))
     manifestBuilder.WriteString("version: 0.1.0\n")
     manifestBuilder.WriteString("licence: Apache-2.0\n")
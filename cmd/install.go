@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/promptbucket/cli/internal/install"
+	"github.com/promptbucket/cli/internal/packager"
+	"github.com/spf13/cobra"
+)
+
+var installCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the packages promptbucket.yaml depends on",
+	Long: `Resolve the dependencies in promptbucket.yaml, including those of the
+packages it depends on, against the registry and install them into
+.promptbucket/packages. The resolved versions are written to
+promptbucket.lock.
+
+Versions already pinned in promptbucket.lock are kept while they satisfy
+every range; pass --update to move everything to the newest versions that
+fit. Installed packages can be rendered with 'promptbucket run org/name'.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		update, _ := cmd.Flags().GetBool("update")
+
+		m, err := packager.LoadManifestFromPath(cmd.Context(), packager.ManifestFile)
+		if err != nil {
+			return err
+		}
+		previous, err := packager.ReadLock(packager.LockFile)
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+		fmt.Println("🔎 Resolving dependencies...")
+		l, resolved, err := resolveProjectLock(cmd.Context(), m, previous, update)
+		if err != nil {
+			return err
+		}
+
+		// Install packages
+		changes, err := install.Install(install.Dir, resolved)
+		if err != nil {
+			return err
+		}
+		for _, c := range changes {
+			switch c.Action {
+			case "added":
+				fmt.Printf("  + %s@%s\n", c.Package, c.Version)
+			case "updated":
+				fmt.Printf("  ~ %s@%s\n", c.Package, c.Version)
+			case "removed":
+				fmt.Printf("  - %s\n", c.Package)
+			default:
+				fmt.Printf("  = %s@%s\n", c.Package, c.Version)
+			}
+		}
+
+		for _, r := range resolved {
+			warnVersionStatus(r.FullName(), r.Status)
+		}
+
+		// Write lockfile
+		if err := packager.WriteLock(packager.LockFile, l); err != nil {
+			return err
+		}
+
+		if len(resolved) == 0 {
+			fmt.Printf("✅ No dependencies to install; wrote %s\n", packager.LockFile)
+			return nil
+		}
+		fmt.Printf("✅ Installed %d package(s) into %s and wrote %s\n", len(resolved), install.Dir, packager.LockFile)
+		return nil
+	},
+}
+
+func init() {
+	installCmd.Flags().Bool("update", false, "Ignore pinned versions and install the newest that satisfy every range")
+	rootCmd.AddCommand(installCmd)
+}
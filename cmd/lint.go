@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/promptbucket/cli/internal/diag"
+	"github.com/promptbucket/cli/internal/lint"
+	"github.com/promptbucket/cli/internal/packager"
+	"github.com/spf13/cobra"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [prompt_name]",
+	Short: "Check a prompt manifest for quality issues",
+	Long: `Check a prompt manifest against quality rules that go beyond schema validity,
+such as prompt length, missing descriptions or tags, and persona settings that
+contradict each other.
+
+Rules are configured in .promptbucket-lint.yaml, looked up next to the manifest
+and then in the current directory:
+
+  rules:
+    prompt-length:
+      severity: error
+      options: { min: 50, max: 4000 }
+    tags-required: off
+
+Individual findings can be suppressed with YAML comments:
+
+  # promptbucket-lint-disable [rule, ...]
+  model_hint: my-model # promptbucket-lint-disable-line model-hint
+  # promptbucket-lint-disable-next-line [rule, ...]`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		configPath, _ := cmd.Flags().GetString("config")
+		listRules, _ := cmd.Flags().GetBool("list-rules")
+
+		if listRules {
+			for _, r := range lint.Rules() {
+				fmt.Printf("%-24s %-8s %s\n", r.ID(), r.DefaultSeverity(), r.Description())
+			}
+			return nil
+		}
+
+		manifestPath := packager.ManifestFile
+		if len(args) > 0 {
+			manifestPath = resolveManifestArg(args[0])
+		}
+
+		// Load lint configuration
+		if configPath == "" {
+			configPath = lint.FindConfig(manifestPath)
+		}
+		var config *lint.Config
+		if configPath != "" {
+			var err error
+			config, err = lint.LoadConfig(configPath)
+			if err != nil {
+				return err
+			}
+		}
+
+		diags, err := lint.File(manifestPath, lint.Options{Config: config})
+		if err != nil {
+			return err
+		}
+
+		if err := diag.Write(os.Stdout, format, "promptbucket-lint", lint.RuleInfos(), diags); err != nil {
+			return err
+		}
+
+		errorCount := diag.Count(diags, diag.SeverityError)
+		if format == "text" {
+			if len(diags) == 0 {
+				fmt.Printf("✅ %s has no lint findings\n", manifestPath)
+			} else {
+				fmt.Printf("\n%d error(s), %d warning(s), %d info\n",
+					errorCount, diag.Count(diags, diag.SeverityWarning), diag.Count(diags, diag.SeverityInfo))
+			}
+		}
+
+		if errorCount > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("lint failed with %d error(s)", errorCount)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+	lintCmd.Flags().StringP("format", "f", "text", "Output format ("+strings.Join(diag.Formats, "|")+")")
+	lintCmd.Flags().String("config", "", "Lint configuration file (default: "+lint.ConfigFile+" next to the manifest)")
+	lintCmd.Flags().Bool("list-rules", false, "List available rules and exit")
+}
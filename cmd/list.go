@@ -1,12 +1,13 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"strings"
+	"time"
 
 	"github.com/promptbucket/cli/internal/auth"
+	"github.com/promptbucket/cli/internal/registry"
 	"github.com/spf13/cobra"
 )
 
@@ -20,184 +21,111 @@ You can list popular packages, trending packages, or search for specific package
 		// Get flags
 		search, _ := cmd.Flags().GetString("search")
 		trending, _ := cmd.Flags().GetBool("trending")
-		popular, _ := cmd.Flags().GetBool("popular")
-		limit, _ := cmd.Flags().GetInt("limit")
+		since, _ := cmd.Flags().GetString("since")
 
-		// Create auth config and API client
-		config := auth.NewConfig()
-		apiClient := auth.NewAPIClient(config)
-
-		// Determine which endpoint to use
-		var endpoint string
-		if search != "" {
-			endpoint = fmt.Sprintf("/packages/search?q=%s", search)
-			if limit > 0 {
-				endpoint = fmt.Sprintf("%s&limit=%d", endpoint, limit)
-			}
-		} else if trending {
-			endpoint = "/packages/trending"
-		} else if popular {
-			endpoint = "/packages/popular"
-		} else {
-			// Default to popular if no specific option
-			endpoint = "/packages/popular"
+		// Filters only apply to searches, and --since only to trending
+		if search == "" && hasSearchFilters(cmd) {
+			return fmt.Errorf("filters and --sort require --search")
 		}
-
-		// Make request
-		fmt.Println("📋 Fetching packages from registry...")
-		resp, err := apiClient.Get(endpoint)
-		if err != nil {
-			return fmt.Errorf("failed to fetch packages: %w", err)
+		if since != "" && !trending {
+			return fmt.Errorf("--since requires --trending")
 		}
-		defer resp.Body.Close()
-
-		// Read response
-		body, err := io.ReadAll(resp.Body)
+		req, err := searchRequest(cmd, search)
 		if err != nil {
-			return fmt.Errorf("failed to read response: %w", err)
+			return err
 		}
-
-		// Check status
-		if resp.StatusCode != 200 {
-			var errorResp map[string]interface{}
-			if err := json.Unmarshal(body, &errorResp); err == nil {
-				if msg, ok := errorResp["message"].(string); ok {
-					return fmt.Errorf("request failed: %s", msg)
-				}
-				if errMsg, ok := errorResp["error"].(string); ok {
-					return fmt.Errorf("request failed: %s", errMsg)
-				}
+		var trend registry.TrendingRequest
+		if since != "" {
+			window, err := parseAge("--since", since)
+			if err != nil {
+				return err
 			}
-			return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+			trend.Since = time.Now().Add(-window)
 		}
 
-		// Parse response based on endpoint type
-		if search != "" {
-			// Search endpoint returns a different structure
-			var searchResp struct {
-				Query   string                   `json:"query"`
-				Results []map[string]interface{} `json:"results"`
-				Total   int                      `json:"total"`
-			}
-			if err := json.Unmarshal(body, &searchResp); err != nil {
-				return fmt.Errorf("failed to parse response: %w", err)
-			}
-
-			if searchResp.Total == 0 {
-				fmt.Printf("No packages found matching \"%s\".\n", search)
-				return nil
-			}
+		// Create registry client
+		client := registry.NewFromConfig(auth.NewConfig())
 
-			fmt.Printf("\nFound %d package(s) matching \"%s\":\n\n", searchResp.Total, search)
-			displayPackages(searchResp.Results)
-		} else {
-			// Popular/trending endpoints might return object with results or array
-			var packages []map[string]interface{}
-			
-			// Try to parse as object with results first
-			var objResp struct {
-				Results []map[string]interface{} `json:"results"`
-				Total   int                      `json:"total"`
-				Limit   int                      `json:"limit"`
-			}
-			
-			if err := json.Unmarshal(body, &objResp); err == nil && objResp.Results != nil {
-				packages = objResp.Results
-			} else {
-				// Try parsing as direct array
-				if err := json.Unmarshal(body, &packages); err != nil {
-					return fmt.Errorf("failed to parse response: %w", err)
-				}
+		// Make request
+		fmt.Println("📋 Fetching packages from registry...")
+		var l listing
+		switch {
+		case search != "":
+			l = listing{
+				list: func(ctx context.Context, opts registry.ListOptions) (*registry.PackageList, error) {
+					req.ListOptions = opts
+					return client.Search(ctx, req)
+				},
+				header: func(total int) { fmt.Printf("\nFound %d package(s) matching \"%s\":\n\n", total, search) },
+				empty:  func() { fmt.Printf("No packages found matching \"%s\".\n", search) },
 			}
-
-			if len(packages) == 0 {
-				fmt.Println("No packages found.")
-				return nil
+		case trending:
+			l = listing{
+				list: func(ctx context.Context, opts registry.ListOptions) (*registry.PackageList, error) {
+					trend.ListOptions = opts
+					return client.Trending(ctx, trend)
+				},
+				header: func(total int) { fmt.Printf("\n📈 Trending packages (%d):\n\n", total) },
 			}
-
-			if trending {
-				fmt.Printf("\n📈 Trending packages (%d):\n\n", len(packages))
-			} else {
-				fmt.Printf("\n⭐ Popular packages (%d):\n\n", len(packages))
+		default:
+			// Default to popular if no specific option
+			l = listing{
+				list:   client.Popular,
+				header: func(total int) { fmt.Printf("\n⭐ Popular packages (%d):\n\n", total) },
 			}
-			displayPackages(packages)
 		}
+		if l.empty == nil {
+			l.empty = func() { fmt.Println("No packages found.") }
+		}
+		l.item = displayPackage
 
+		if err := printListing(cmd, l); err != nil {
+			return fmt.Errorf("request failed: %w", err)
+		}
 		return nil
 	},
 }
 
-func displayPackages(packages []map[string]interface{}) {
-	for i, pkg := range packages {
-		// Extract package info
-		name, _ := pkg["name"].(string)
-		orgName, _ := pkg["org_name"].(string)
-		if orgName == "" {
-			orgName, _ = pkg["org"].(string)
-		}
-		description, _ := pkg["description"].(string)
-		
-		// Display package number
-		fmt.Printf("%d. ", i+1)
-		
-		// Display package name with org
-		if orgName != "" && name != "" {
-			fmt.Printf("📦 %s/%s", orgName, name)
-		} else if name != "" {
-			fmt.Printf("📦 %s", name)
-		} else if id, ok := pkg["id"].(string); ok {
-			fmt.Printf("📦 %s", id)
-		}
-
-		// Show stats if available
-		if starCount, ok := getFloat(pkg["star_count"]); ok && starCount > 0 {
-			fmt.Printf(" ⭐ %.0f", starCount)
-		}
-		if pullCount, ok := getFloat(pkg["pull_count"]); ok && pullCount > 0 {
-			fmt.Printf(" 📥 %.0f", pullCount)
-		}
-		
-		fmt.Println()
-		
-		// Show description
-		if description != "" {
-			fmt.Printf("   %s\n", description)
-		}
-		
-		// Show tags if available
-		if tags, ok := pkg["tags"].([]interface{}); ok && len(tags) > 0 {
-			tagStrings := make([]string, 0, len(tags))
-			for _, tag := range tags {
-				if tagStr, ok := tag.(string); ok {
-					tagStrings = append(tagStrings, tagStr)
-				}
-			}
-			if len(tagStrings) > 0 {
-				fmt.Printf("   Tags: %s\n", strings.Join(tagStrings, ", "))
-			}
-		}
-		
-		// Show how to get more info
-		if orgName != "" && name != "" {
-			fmt.Printf("   Info: promptbucket info %s/%s\n", orgName, name)
-		}
-		
-		fmt.Println()
+func displayPackage(n int, pkg registry.Package) {
+	// Display package number
+	fmt.Printf("%d. ", n)
+	
+	// Display package name with org
+	if pkg.Name != "" {
+		fmt.Printf("📦 %s", pkg.FullName())
+	} else if pkg.ID != "" {
+		fmt.Printf("📦 %s", pkg.ID)
 	}
-}
 
-// Helper function to get float value from interface{}
-func getFloat(v interface{}) (float64, bool) {
-	switch val := v.(type) {
-	case float64:
-		return val, true
-	case int:
-		return float64(val), true
-	case int64:
-		return float64(val), true
-	default:
-		return 0, false
+	// Show visibility, stats if available
+	if label := visibilityLabel(pkg.Visibility); label != "" {
+		fmt.Printf(" %s", label)
+	}
+	if pkg.StarCount > 0 {
+		fmt.Printf(" ⭐ %d", pkg.StarCount)
+	}
+	if pkg.PullCount > 0 {
+		fmt.Printf(" 📥 %d", pkg.PullCount)
+	}
+	
+	fmt.Println()
+	
+	// Show description
+	if pkg.Description != "" {
+		fmt.Printf("   %s\n", pkg.Description)
+	}
+	
+	// Show tags if available
+	if len(pkg.Tags) > 0 {
+		fmt.Printf("   Tags: %s\n", strings.Join(pkg.Tags, ", "))
+	}
+	
+	// Show how to get more info
+	if pkg.Org != "" && pkg.Name != "" {
+		fmt.Printf("   Info: promptbucket info %s\n", pkg.FullName())
 	}
+	
+	fmt.Println()
 }
 
 func init() {
@@ -205,5 +133,8 @@ func init() {
 	listCmd.Flags().StringP("search", "s", "", "Search packages by keyword")
 	listCmd.Flags().BoolP("trending", "t", false, "Show trending packages")
 	listCmd.Flags().BoolP("popular", "p", false, "Show popular packages")
-	listCmd.Flags().Int("limit", 20, "Maximum number of packages to display")
+	listCmd.Flags().Int("limit", 20, "Number of packages per page")
+	listCmd.Flags().String("since", "", "Trending window, e.g. 24h, 7d or 4w (with --trending)")
+	addPagingFlags(listCmd)
+	addSearchFilterFlags(listCmd)
 }
\ No newline at end of file
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/promptbucket/cli/internal/auth"
+	"github.com/promptbucket/cli/internal/install"
+	"github.com/promptbucket/cli/internal/packager"
+	"github.com/promptbucket/cli/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Pin remote parents and dependencies in promptbucket.lock",
+	Long: `Resolve every remote manifest promptbucket.yaml uses, its from: URL
+parents and its dependencies, and record each one's name, version and digest
+in promptbucket.lock.
+
+Once the lockfile exists, build and run use the pinned content and fail if a
+source has changed. Entries already in the lockfile are kept as they are;
+new sources are added and ones no longer used are dropped. Use --update to
+fetch everything again and re-pin it, and --check in CI to fail when the
+lockfile is out of date.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		update, _ := cmd.Flags().GetBool("update")
+		check, _ := cmd.Flags().GetBool("check")
+		if update && check {
+			return fmt.Errorf("--update and --check cannot be used together")
+		}
+
+		m, err := packager.LoadManifestFromPath(cmd.Context(), packager.ManifestFile)
+		if err != nil {
+			return err
+		}
+		previous, err := packager.ReadLock(packager.LockFile)
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+		l, _, err := resolveProjectLock(cmd.Context(), m, previous, update)
+		if err != nil {
+			return err
+		}
+		changes := printLockChanges(previous, l)
+		if check {
+			if previous == nil {
+				return fmt.Errorf("%s does not exist; run 'promptbucket lock'", packager.LockFile)
+			}
+			if changes > 0 {
+				return fmt.Errorf("%s is out of date (%d change(s)); run 'promptbucket lock'", packager.LockFile, changes)
+			}
+			fmt.Printf("✅ %s is up to date\n", packager.LockFile)
+			return nil
+		}
+
+		if previous != nil && changes == 0 {
+			fmt.Printf("✅ %s is up to date (%d pinned)\n", packager.LockFile, len(l.Packages))
+			return nil
+		}
+		if err := packager.WriteLock(packager.LockFile, l); err != nil {
+			return err
+		}
+		fmt.Printf("🔒 Wrote %s (%d pinned, %d change(s))\n", packager.LockFile, len(l.Packages), changes)
+		return nil
+	},
+}
+
+// resolveProjectLock pins the project's remote parents and resolves its
+// dependencies against the registry.
+func resolveProjectLock(ctx context.Context, m *packager.Manifest, previous *packager.Lock, update bool) (*packager.Lock, []*install.Resolved, error) {
+	l, err := packager.ResolveLock(ctx, m, previous, update)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Dependencies may be declared by parents too
+	flattened, err := packager.FlattenManifestLocked(ctx, m, l)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(flattened.Dependencies) == 0 {
+		return l, nil, nil
+	}
+	resolver := &install.Resolver{
+		ClientFor: func(org, name string) *registry.Client {
+			return registry.NewFromConfig(auth.ConfigForPackage(org, name))
+		},
+		Lock:   previous,
+		Update: update,
+	}
+	resolved, err := resolver.Resolve(ctx, flattened)
+	if err != nil {
+		return nil, nil, err
+	}
+	l.Packages = append(l.Packages, install.LockEntries(resolved)...)
+	return l, resolved, nil
+}
+
+// printLockChanges lists each entry of l as added (+), changed (~) or
+// unchanged (=) against previous, then entries dropped (-), and returns the
+// number of changes.
+func printLockChanges(previous, l *packager.Lock) int {
+	changes := 0
+	for _, e := range l.Packages {
+		old, ok := previous.Find(e.Kind, e.Source)
+		switch {
+		case !ok:
+			fmt.Printf("  + %s %s (%s)\n", e.Kind, e.Source, describeLockEntry(e))
+			changes++
+		case old.Digest != e.Digest:
+			fmt.Printf("  ~ %s %s (%s → %s)\n", e.Kind, e.Source, describeLockEntry(old), describeLockEntry(e))
+			changes++
+		default:
+			fmt.Printf("  = %s %s (%s)\n", e.Kind, e.Source, describeLockEntry(e))
+		}
+	}
+	if previous != nil {
+		for _, old := range previous.Packages {
+			if _, ok := l.Find(old.Kind, old.Source); !ok {
+				fmt.Printf("  - %s %s (%s)\n", old.Kind, old.Source, describeLockEntry(old))
+				changes++
+			}
+		}
+	}
+	return changes
+}
+
+// describeLockEntry summarises a lock entry as "name@version sha256:abc…".
+func describeLockEntry(e packager.LockEntry) string {
+	desc := shortDigest(e.Digest)
+	if e.Name != "" {
+		desc = fmt.Sprintf("%s@%s %s", e.Name, e.Version, desc)
+	}
+	return desc
+}
+
+func init() {
+	lockCmd.Flags().Bool("update", false, "Fetch every source again and re-pin it")
+	lockCmd.Flags().Bool("check", false, "Fail if the lockfile is missing or out of date, without writing it")
+	rootCmd.AddCommand(lockCmd)
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -52,6 +53,9 @@ then save your credentials locally for future CLI use.`,
 
 		fmt.Printf("🔐 Starting PromptBucket authentication...\n")
 		fmt.Printf("   Provider: %s\n", provider)
+		if config.Registry != auth.DefaultRegistry {
+			fmt.Printf("   Registry: %s (%s)\n", config.Registry, config.BaseURL)
+		}
 		fmt.Printf("   Callback port: %d\n", config.CallbackPort)
 
 		// Create OAuth server
@@ -63,7 +67,7 @@ then save your credentials locally for future CLI use.`,
 		}
 
 		// Start OAuth server with timeout
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Minute)
 		defer cancel()
 
 		fmt.Printf("⏳ Starting local callback server...\n")
@@ -117,6 +121,9 @@ then save your credentials locally for future CLI use.`,
 			}
 		case <-ctx.Done():
 			oauthServer.Stop()
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return fmt.Errorf("login cancelled")
+			}
 			return fmt.Errorf("authentication timeout - no response received within 5 minutes")
 		}
 	},
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/promptbucket/cli/internal/atomicfile"
+	"github.com/promptbucket/cli/internal/packager"
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate [prompt_name...]",
+	Short: "Upgrade manifests to the current schema version",
+	Long: fmt.Sprintf(`Rewrite manifests written for an older schema_version to version %d.
+
+Comments, key order and formatting are preserved. With --check, nothing is written and the
+command fails if any manifest needs migrating, which suits CI.`, packager.SchemaVersion),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		check, _ := cmd.Flags().GetBool("check")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		paths := []string{packager.ManifestFile}
+		if len(args) > 0 {
+			paths = paths[:0]
+			for _, arg := range args {
+				paths = append(paths, resolveManifestArg(arg))
+			}
+		}
+
+		outdated := 0
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read manifest file %s: %w", path, err)
+			}
+
+			migrated, steps, err := packager.Migrate(data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if len(steps) == 0 {
+				fmt.Printf("✅ %s is already at schema_version %d\n", path, packager.SchemaVersion)
+				continue
+			}
+			outdated++
+
+			switch {
+			case check:
+				fmt.Printf("⚠️  %s needs migrating to schema_version %d\n", path, packager.SchemaVersion)
+			case dryRun:
+				fmt.Printf("🔍 %s would be rewritten as:\n\n", path)
+				os.Stdout.Write(migrated)
+			default:
+				if bytes.Equal(migrated, data) {
+					continue
+				}
+				if err := atomicfile.Write(path, migrated, 0644); err != nil {
+					return fmt.Errorf("failed to write %s: %w", path, err)
+				}
+				fmt.Printf("✅ Migrated %s to schema_version %d\n", path, packager.SchemaVersion)
+			}
+			for _, step := range steps {
+				fmt.Printf("   • %s\n", step)
+			}
+		}
+
+		if check && outdated > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d manifest(s) need migrating; run 'promptbucket migrate'", outdated)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.Flags().Bool("check", false, "Report manifests that need migrating without changing them")
+	migrateCmd.Flags().Bool("dry-run", false, "Print migrated manifests instead of writing them")
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/promptbucket/cli/internal/auth"
+	"github.com/promptbucket/cli/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+// orgNamePattern matches valid org namespaces, as in dependency names.
+var orgNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+var orgCmd = &cobra.Command{
+	Use:   "org",
+	Short: "Manage organization namespaces and their members",
+	Long: `Manage organizations: shared namespaces that a team publishes packages to
+with 'promptbucket push --namespace org'.
+
+Members have one of these roles:
+  owner   everything, including managing owners
+  admin   manage members and packages
+  writer  publish packages to the org
+  reader  see and pull the org's private packages`,
+}
+
+var orgCreateCmd = &cobra.Command{
+	Use:   "create name",
+	Short: "Create an organization you own",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		displayName, _ := cmd.Flags().GetString("display-name")
+		name := args[0]
+		if !orgNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid org name %q (use lowercase letters, digits and dashes)", name)
+		}
+
+		client := registry.NewFromConfig(auth.ConfigForPackage(name, ""))
+		cmd.SilenceUsage = true
+
+		org, err := client.CreateOrg(cmd.Context(), name, displayName)
+		if registry.IsConflict(err) {
+			return fmt.Errorf("the namespace %s is already taken", name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create org: %w", err)
+		}
+		fmt.Printf("✅ Created org %s; you are its %s\n", org.Name, org.Role)
+		fmt.Printf("   Publish to it with: promptbucket push --namespace %s\n", org.Name)
+		return nil
+	},
+}
+
+var orgListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the organizations you belong to",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client := registry.NewFromConfig(auth.NewConfig())
+		cmd.SilenceUsage = true
+
+		orgs, err := client.Orgs(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to list orgs: %w", err)
+		}
+		if len(orgs) == 0 {
+			fmt.Println("You are not a member of any org. Create one with 'promptbucket org create name'.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ORG\tROLE\tMEMBERS\tPACKAGES")
+		for _, o := range orgs {
+			name := o.Name
+			if o.DisplayName != "" {
+				name += " (" + o.DisplayName + ")"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\n", name, o.Role, o.MemberCount, o.PackageCount)
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+var orgMembersCmd = &cobra.Command{
+	Use:   "members",
+	Short: "List and manage an organization's members",
+}
+
+var orgMembersListCmd = &cobra.Command{
+	Use:   "list org",
+	Short: "List an organization's members and their roles",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		org := args[0]
+		client := registry.NewFromConfig(auth.ConfigForPackage(org, ""))
+		cmd.SilenceUsage = true
+
+		members, err := client.Members(cmd.Context(), org)
+		if err != nil {
+			return orgError(err, org, "list members of")
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "USER\tROLE\tEMAIL")
+		for _, m := range members {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", m.Username, m.Role, m.Email)
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+var orgMembersAddCmd = &cobra.Command{
+	Use:   "add org user",
+	Short: "Add a user to an organization",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		org, user := args[0], args[1]
+		role, _ := cmd.Flags().GetString("role")
+		role = strings.ToLower(role)
+		if err := checkRole(role); err != nil {
+			return err
+		}
+
+		client := registry.NewFromConfig(auth.ConfigForPackage(org, ""))
+		cmd.SilenceUsage = true
+
+		if err := client.AddMember(cmd.Context(), org, user, role); err != nil {
+			if registry.IsConflict(err) {
+				return fmt.Errorf("%s is already a member of %s; change their role with 'promptbucket org members role %s %s %s'", user, org, org, user, role)
+			}
+			return orgError(err, org, "add members to")
+		}
+		fmt.Printf("✅ Added %s to %s as %s\n", user, org, role)
+		return nil
+	},
+}
+
+var orgMembersRemoveCmd = &cobra.Command{
+	Use:   "remove org user",
+	Short: "Remove a user from an organization",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		org, user := args[0], args[1]
+		client := registry.NewFromConfig(auth.ConfigForPackage(org, ""))
+		cmd.SilenceUsage = true
+
+		if err := client.RemoveMember(cmd.Context(), org, user); err != nil {
+			return orgError(err, org, "remove members from")
+		}
+		fmt.Printf("✅ Removed %s from %s\n", user, org)
+		return nil
+	},
+}
+
+var orgMembersRoleCmd = &cobra.Command{
+	Use:   "role org user owner|admin|writer|reader",
+	Short: "Change a member's role",
+	Args:  cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		org, user, role := args[0], args[1], strings.ToLower(args[2])
+		if err := checkRole(role); err != nil {
+			return err
+		}
+
+		client := registry.NewFromConfig(auth.ConfigForPackage(org, ""))
+		cmd.SilenceUsage = true
+
+		if err := client.SetRole(cmd.Context(), org, user, role); err != nil {
+			return orgError(err, org, "change roles in")
+		}
+		fmt.Printf("✅ %s is now %s of %s\n", user, role, org)
+		return nil
+	},
+}
+
+// checkRole returns an error unless role is a known member role.
+func checkRole(role string) error {
+	for _, known := range registry.Roles {
+		if role == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid role %q (expected %s)", role, strings.Join(registry.Roles, ", "))
+}
+
+// orgError explains a failed org request, turning permission and
+// not-found responses into advice.
+func orgError(err error, org, action string) error {
+	switch {
+	case registry.IsForbidden(err):
+		return fmt.Errorf("you cannot %s %s: %w", action, org, err)
+	case registry.IsNotFound(err):
+		return fmt.Errorf("org or member not found: %w", err)
+	}
+	return fmt.Errorf("failed to %s %s: %w", action, org, err)
+}
+
+func init() {
+	orgCreateCmd.Flags().String("display-name", "", "Human-readable name shown for the org")
+	orgMembersAddCmd.Flags().String("role", registry.RoleWriter, "Role to give the user: owner, admin, writer or reader")
+
+	orgMembersCmd.AddCommand(orgMembersListCmd, orgMembersAddCmd, orgMembersRemoveCmd, orgMembersRoleCmd)
+	orgCmd.AddCommand(orgCreateCmd, orgListCmd, orgMembersCmd)
+	rootCmd.AddCommand(orgCmd)
+}
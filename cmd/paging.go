@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/promptbucket/cli/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+// addPagingFlags adds --page, --offset and --all to a listing command. The
+// page size comes from the command's own --limit flag.
+func addPagingFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("page", 0, "Page of results to show, starting at 1")
+	cmd.Flags().Int("offset", 0, "Number of results to skip")
+	cmd.Flags().Bool("all", false, "Show every result, fetching further pages as needed")
+}
+
+// listing describes how a command prints a paged package listing.
+type listing struct {
+	// list fetches one page.
+	list registry.ListFunc
+	// header is printed before the first result, with the total count.
+	header func(total int)
+	// empty is printed when there are no results.
+	empty func()
+	// item prints the n-th result, counting from 1 across pages.
+	item func(n int, pkg registry.Package)
+}
+
+// printListing prints the page selected by the paging flags, or every page
+// with --all, followed by a "showing X of Y" footer.
+func printListing(cmd *cobra.Command, l listing) error {
+	limit, _ := cmd.Flags().GetInt("limit")
+	page, _ := cmd.Flags().GetInt("page")
+	offset, _ := cmd.Flags().GetInt("offset")
+	all, _ := cmd.Flags().GetBool("all")
+
+	if page < 0 || offset < 0 {
+		return fmt.Errorf("--page and --offset must not be negative")
+	}
+	if page > 0 && offset > 0 {
+		return fmt.Errorf("--page and --offset cannot be used together")
+	}
+	if page > 0 {
+		if limit <= 0 {
+			return fmt.Errorf("--page requires a positive --limit")
+		}
+		offset = (page - 1) * limit
+	}
+	opts := registry.ListOptions{Limit: limit, Offset: offset}
+
+	// Walk every page lazily, printing results as they arrive
+	if all {
+		it := registry.Iterate(l.list, opts)
+		shown := 0
+		for it.Next(cmd.Context()) {
+			if shown == 0 {
+				l.header(it.Total())
+			}
+			shown++
+			l.item(offset+shown, it.Package())
+		}
+		if err := it.Err(); err != nil {
+			return err
+		}
+		if shown == 0 {
+			l.empty()
+			return nil
+		}
+		fmt.Printf("Showing %s of %d\n", resultRange(offset, shown), it.Total())
+		return nil
+	}
+
+	result, err := l.list(cmd.Context(), opts)
+	if err != nil {
+		return err
+	}
+	if len(result.Results) == 0 {
+		if result.Total > 0 && offset > 0 {
+			fmt.Printf("No results at offset %d; there are %d in total.\n", offset, result.Total)
+			return nil
+		}
+		l.empty()
+		return nil
+	}
+
+	l.header(result.Total)
+	for i, pkg := range result.Results {
+		l.item(offset+i+1, pkg)
+	}
+
+	total := result.Total
+	if total < offset+len(result.Results) {
+		total = offset + len(result.Results)
+	}
+	fmt.Printf("Showing %s of %d", resultRange(offset, len(result.Results)), total)
+	if next := offset + len(result.Results); next < total {
+		if limit > 0 && offset%limit == 0 {
+			fmt.Printf(" · next: --page %d, or --all", next/limit+1)
+		} else {
+			fmt.Printf(" · next: --offset %d, or --all", next)
+		}
+	}
+	fmt.Println()
+	return nil
+}
+
+// resultRange formats the 1-based range of n results starting after offset.
+func resultRange(offset, n int) string {
+	if n == 1 {
+		return fmt.Sprintf("%d", offset+1)
+	}
+	return fmt.Sprintf("%d-%d", offset+1, offset+n)
+}
@@ -1,13 +1,15 @@
 package cmd
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"github.com/promptbucket/cli/internal/auth"
+	"github.com/promptbucket/cli/internal/atomicfile"
+	"github.com/promptbucket/cli/internal/registry"
 	"github.com/spf13/cobra"
 )
 
@@ -18,62 +20,77 @@ var pullCmd = &cobra.Command{
 
 Package can be specified as:
   - org/name:version (e.g., rawte.mayur/Ui-Artist:0.1.0)
-  - org/name (pulls latest version)`,
+  - org/name@range (e.g., rawte.mayur/Ui-Artist@^0.1, @~1.2.3, "@>=1.0 <2")
+  - org/name:@tag (the version a dist-tag such as beta or stable points at)
+  - org/name (the version tagged latest, or else the latest stable version)
+
+Ranges are resolved against the published versions and the highest match is
+pulled. Pre-releases are only chosen when the range names one.`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		packageSpec := args[0]
 		outputDir, _ := cmd.Flags().GetString("output")
 
 		// Parse package specification
-		var org, packageName, version string
-		
-		// Split by / to get org and package
-		parts := strings.Split(packageSpec, "/")
-		if len(parts) != 2 {
-			return fmt.Errorf("invalid package format: %s (expected org/name[:version])", packageSpec)
-		}
-		org = parts[0]
-		
-		// Check for version in the package name part
-		if idx := strings.LastIndex(parts[1], ":"); idx > 0 {
-			packageName = parts[1][:idx]
-			version = parts[1][idx+1:]
-		} else {
-			packageName = parts[1]
-			// For now, we'll need to specify version explicitly
-			// TODO: Add API endpoint to get latest version
-			return fmt.Errorf("please specify version (e.g., %s:0.1.0)", packageSpec)
+		ref, err := parsePackageRef(packageSpec)
+		if err != nil {
+			return err
 		}
+		org, packageName, version := ref.Org, ref.Name, ref.Version
+		cmd.SilenceUsage = true
 
-		// Create auth config and API client
-		config := auth.NewConfig()
-		apiClient := auth.NewAPIClient(config)
+		// Create registry client
+		client, err := ref.client()
+		if err != nil {
+			return err
+		}
 
-		// Build endpoint for manifest
-		endpoint := fmt.Sprintf("/manifests/%s/%s/%s", org, packageName, version)
+		// Resolve latest or a range against the published versions
+		var resolved registry.Version
+		if version == "" {
+			pkg, err := client.Package(cmd.Context(), org, packageName)
+			if registry.IsNotFound(err) {
+				return fmt.Errorf("package not found: %s/%s", org, packageName)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get package info: %w", err)
+			}
+			resolved, err = registry.Resolve(pkg, ref.resolvable())
+			if err != nil {
+				return err
+			}
+			version = resolved.Tag
+			if ref.resolvable() != "" {
+				fmt.Printf("🔎 Resolved %s to %s\n", ref, version)
+			} else {
+				fmt.Printf("🔎 Latest version of %s is %s\n", ref, version)
+			}
+		} else if pkg, err := client.Package(cmd.Context(), org, packageName); err == nil {
+			// Look up the exact version's status; pulling does not depend on it
+			for _, v := range pkg.Versions {
+				if v.Tag == version {
+					resolved = v
+				}
+			}
+		}
+		warnVersionStatus(org+"/"+packageName, resolved)
 
 		// Download manifest
 		fmt.Printf("📥 Pulling %s/%s:%s...\n", org, packageName, version)
 		
-		resp, err := apiClient.Get(endpoint)
-		if err != nil {
-			return fmt.Errorf("failed to pull package: %w", err)
-		}
-		defer resp.Body.Close()
-
-		// Check status
-		if resp.StatusCode == 404 {
+		manifestData, err := client.Manifest(cmd.Context(), org, packageName, version)
+		if registry.IsNotFound(err) {
 			return fmt.Errorf("package not found: %s/%s:%s", org, packageName, version)
 		}
-		if resp.StatusCode != 200 {
-			body, _ := io.ReadAll(resp.Body)
-			return fmt.Errorf("pull failed with status %d: %s", resp.StatusCode, string(body))
+		if err != nil {
+			return fmt.Errorf("failed to pull package: %w", err)
 		}
 
-		// Read manifest data
-		manifestData, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("failed to download manifest: %w", err)
+		// Verify the download against the registry's digest, if it gave one
+		hash := sha256.Sum256(manifestData)
+		digest := hex.EncodeToString(hash[:])
+		if want := strings.TrimPrefix(resolved.Digest, "sha256:"); want != "" && !strings.EqualFold(want, digest) {
+			return fmt.Errorf("digest mismatch for %s/%s:%s: registry has sha256:%s, downloaded sha256:%s", org, packageName, version, want, digest)
 		}
 
 		// Generate filename
@@ -90,12 +107,14 @@ Package can be specified as:
 		}
 
 		// Write manifest file
-		if err := os.WriteFile(outputPath, manifestData, 0644); err != nil {
+		if err := atomicfile.Write(outputPath, manifestData, 0644); err != nil {
 			return fmt.Errorf("failed to save manifest: %w", err)
 		}
 
 		fileInfo, _ := os.Stat(outputPath)
 		fmt.Printf("✅ Downloaded %s (%.2f KB)\n", outputPath, float64(fileInfo.Size())/1024)
+		fmt.Printf("   Version: %s\n", version)
+		fmt.Printf("   Digest: sha256:%s\n", digest)
 
 		// Show next steps
 		fmt.Println("\nNext steps:")
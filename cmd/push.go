@@ -1,16 +1,21 @@
 package cmd
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/promptbucket/cli/internal/auth"
+	"github.com/promptbucket/cli/internal/diag"
+	"github.com/promptbucket/cli/internal/lint"
 	"github.com/promptbucket/cli/internal/packager"
+	"github.com/promptbucket/cli/internal/registry"
+	"github.com/promptbucket/cli/internal/semver"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
@@ -21,15 +26,40 @@ var pushCmd = &cobra.Command{
 	Long: `Push a .promptbucket package to the PromptBucket registry.
 
 This command builds the package from the current directory's promptbucket.yaml
-and uploads it to the registry. You must be authenticated to push packages.`,
+and uploads it to the registry. You must be authenticated to push packages.
+
+With --dry-run nothing is uploaded. Instead the manifest is validated, linted
+and scanned for secrets, the namespace and version are checked against the
+registry, and the changes since the latest published version are shown along
+with the exact request push would send.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// Create auth config and API client
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		visibility, _ := cmd.Flags().GetString("visibility")
+		visibility = strings.ToLower(visibility)
+		if visibility != "" {
+			if err := checkVisibility(visibility); err != nil {
+				return err
+			}
+		}
+		tag, _ := cmd.Flags().GetString("tag")
+		if tag != "" {
+			if err := registry.CheckDistTag(tag); err != nil {
+				return err
+			}
+		}
+
+		// Create auth config and API client for the registry that serves
+		// the namespace
+		requested, _ := cmd.Flags().GetString("namespace")
 		config := auth.NewConfig()
+		if requested != "" {
+			config = auth.ConfigForPackage(requested, "")
+		}
 		apiClient := auth.NewAPIClient(config)
 
 		// Check if authenticated
 		if !apiClient.IsAuthenticated() {
-			return fmt.Errorf("not authenticated. Run 'promptbucket login' first")
+			return fmt.Errorf("not authenticated. Run '%s' first", config.LoginCommand())
 		}
 
 		// Get current user info
@@ -39,6 +69,9 @@ and uploads it to the registry. You must be authenticated to push packages.`,
 		}
 
 		fmt.Printf("🚀 Pushing package as %s\n", user.Email)
+		if config.Registry != auth.DefaultRegistry {
+			fmt.Printf("   Registry: %s (%s)\n", config.Registry, config.BaseURL)
+		}
 
 		// Read the manifest
 		manifestData, err := os.ReadFile(packager.ManifestFile)
@@ -46,6 +79,20 @@ and uploads it to the registry. You must be authenticated to push packages.`,
 			return fmt.Errorf("failed to read manifest: %w", err)
 		}
 
+		// A dry run collects everything that would block the push and
+		// reports it at the end
+		var blocked []string
+		if dryRun {
+			fmt.Println("🧪 Dry run: nothing will be uploaded")
+			errorCount, err := checkBeforePush(cmd.Context(), packager.ManifestFile)
+			if err != nil {
+				return err
+			}
+			if errorCount > 0 {
+				blocked = append(blocked, fmt.Sprintf("%d validation, lint or secret scan error(s)", errorCount))
+			}
+		}
+
 		var manifest packager.Manifest
 		if err := yaml.Unmarshal(manifestData, &manifest); err != nil {
 			return fmt.Errorf("failed to parse manifest: %w", err)
@@ -55,85 +102,125 @@ and uploads it to the registry. You must be authenticated to push packages.`,
 		if manifest.Name == "" || manifest.Version == "" || manifest.Licence == "" || manifest.Prompt == "" {
 			return fmt.Errorf("manifest missing required fields (name, version, licence, prompt)")
 		}
+		if err := packager.CheckCompatibility(&manifest); err != nil {
+			return err
+		}
+
+		// Refuse to publish credentials or personal data; a dry run has
+		// already scanned
+		if !dryRun {
+			if err := requireNoSecrets("push", packager.ManifestFile, packager.BundleFiles()); err != nil {
+				return err
+			}
+		}
+
+		// Resolve the namespace to publish under
+		client := registry.New(apiClient)
+		namespace, err := resolveNamespace(cmd.Context(), client, user, requested)
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
 
-		// Extract username from email (everything before @)
-		username := user.Email
-		if idx := strings.Index(user.Email, "@"); idx != -1 {
-			username = user.Email[:idx]
+		// Check the version against what the registry already has
+		version, err := semver.Parse(manifest.Version)
+		if err != nil {
+			return err
+		}
+		// published stays nil on first publish
+		published, err := client.Package(cmd.Context(), namespace, manifest.Name)
+		if err != nil && !registry.IsNotFound(err) {
+			return fmt.Errorf("failed to get published versions: %w", err)
+		}
+		if err := checkVersionPolicy(published, version); err != nil {
+			if !dryRun {
+				cmd.SilenceUsage = true
+				return err
+			}
+			fmt.Printf("❌ %s\n", err)
+			blocked = append(blocked, "version check failed")
+		}
+		if version.IsPrerelease() && tag == "" {
+			fmt.Printf("⚠️  %s is a pre-release but no --tag was given; consumers resolving the latest version may receive it\n", version)
 		}
 
 		// Calculate digest
 		hash := sha256.Sum256(manifestData)
 		digest := hex.EncodeToString(hash[:])
 
-		fmt.Printf("📦 Pushing %s/%s:%s\n", username, manifest.Name, manifest.Version)
+		fmt.Printf("📦 Pushing %s/%s:%s\n", namespace, manifest.Name, manifest.Version)
 		fmt.Printf("   Digest: sha256:%s\n", digest)
-
-		// Upload the manifest using the manifest endpoint
-		endpoint := fmt.Sprintf("/manifests/%s/%s/%s", username, manifest.Name, manifest.Version)
-		fmt.Println("📤 Uploading to registry...")
-
-		// Create request with YAML body
-		req, err := http.NewRequest("PUT", config.GetAPIURL(endpoint), strings.NewReader(string(manifestData)))
-		if err != nil {
-			return fmt.Errorf("failed to create request: %w", err)
+		switch {
+		case visibility == "" && published == nil:
+			fmt.Println("⚠️  No --visibility given; the registry's default visibility applies to this new package")
+		case visibility == "":
+			fmt.Printf("   Visibility: %s\n", visibilityLabel(published.Visibility))
+		case published != nil && published.Visibility != "" && published.Visibility != visibility:
+			fmt.Printf("   Visibility: %s (was %s)\n", visibilityLabel(visibility), published.Visibility)
+		default:
+			fmt.Printf("   Visibility: %s\n", visibilityLabel(visibility))
 		}
 
-		// Set headers
-		req.Header.Set("Content-Type", "application/x-yaml")
-		req.Header.Set("User-Agent", "promptbucket-cli")
-
-		// Get auth headers directly
-		tokenManager := auth.NewTokenManager(config)
-		if tokenManager.IsAuthenticated() {
-			headers, err := tokenManager.GetAuthHeaders()
-			if err != nil {
-				return fmt.Errorf("failed to get auth headers: %w", err)
+		req := registry.PushRequest{
+			Org:        namespace,
+			Name:       manifest.Name,
+			Version:    manifest.Version,
+			Manifest:   manifestData,
+			Tag:        tag,
+			Visibility: visibility,
+		}
+		if dryRun {
+			cmd.SilenceUsage = true
+			if err := printPublishedDiff(cmd.Context(), client, published, manifestData); err != nil {
+				return err
 			}
-			for key, value := range headers {
-				req.Header.Set(key, value)
+			preview, err := client.PushPreview(cmd.Context(), req)
+			if err != nil {
+				return err
 			}
-		}
+			printRequest(preview, manifestData)
 
-		// Make request using http client
-		httpClient := &http.Client{}
-		resp, err := httpClient.Do(req)
-		if err != nil {
-			return fmt.Errorf("failed to upload package: %w", err)
+			if len(blocked) > 0 {
+				return fmt.Errorf("push would fail: %s", strings.Join(blocked, "; "))
+			}
+			fmt.Println("\n✅ Dry run passed; run without --dry-run to publish")
+			return nil
 		}
-		defer resp.Body.Close()
 
-		// Read response
-		respBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("failed to read response: %w", err)
+		// Upload the manifest
+		fmt.Println("📤 Uploading to registry...")
+		err = client.Push(cmd.Context(), req)
+		if registry.IsConflict(err) {
+			return fmt.Errorf("package version %s/%s:%s already exists", namespace, manifest.Name, manifest.Version)
 		}
-
-		// Check status
-		if resp.StatusCode == 409 {
-			return fmt.Errorf("package version %s/%s:%s already exists", username, manifest.Name, manifest.Version)
+		if registry.IsForbidden(err) {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("you cannot publish to %s: %w", namespace, err)
 		}
-		if resp.StatusCode != 200 && resp.StatusCode != 201 {
-			return fmt.Errorf("upload failed with status %d: %s", resp.StatusCode, string(respBody))
+		if err != nil {
+			return fmt.Errorf("upload failed: %w", err)
 		}
 
 		// Success!
 		fmt.Println("✅ Package pushed successfully!")
-		fmt.Printf("   Package: %s/%s:%s\n", username, manifest.Name, manifest.Version)
+		fmt.Printf("   Package: %s/%s:%s\n", namespace, manifest.Name, manifest.Version)
 		fmt.Printf("   Digest: sha256:%s\n", digest)
+		if tag != "" {
+			fmt.Printf("   Dist-tag: %s → %s\n", tag, manifest.Version)
+		}
 		
 		// Show how to pull the package
 		fmt.Println("\nTo pull this package:")
-		fmt.Printf("   promptbucket pull %s/%s:%s\n", username, manifest.Name, manifest.Version)
+		fmt.Printf("   promptbucket pull %s/%s:%s\n", namespace, manifest.Name, manifest.Version)
 		
 		// Show how to fetch and run
 		fmt.Println("\nTo fetch and run:")
-		fmt.Printf("   promptbucket fetch https://api.promptbucket.co/v1/manifests/%s/%s/%s\n", username, manifest.Name, manifest.Version)
+		fmt.Printf("   promptbucket fetch %s\n", config.GetAPIURL(fmt.Sprintf("/manifests/%s/%s/%s", namespace, manifest.Name, manifest.Version)))
 
 		// Optionally build the .promptbucket archive file
 		if buildFlag, _ := cmd.Flags().GetBool("build"); buildFlag {
 			fmt.Println("\n📦 Building local package archive...")
-			artifact, err := packager.Build()
+			artifact, err := packager.Build(cmd.Context())
 			if err != nil {
 				fmt.Printf("⚠️  Warning: Could not build package archive: %v\n", err)
 			} else {
@@ -145,7 +232,188 @@ and uploads it to the registry. You must be authenticated to push packages.`,
 	},
 }
 
+// checkBeforePush runs validation, including the secret scan, and lint on a
+// manifest, prints what they find and returns the number of errors.
+func checkBeforePush(ctx context.Context, path string) (int, error) {
+	diags, err := checkManifest(ctx, path, false)
+	if err != nil {
+		return 0, err
+	}
+	var config *lint.Config
+	if configPath := lint.FindConfig(path); configPath != "" {
+		if config, err = lint.LoadConfig(configPath); err != nil {
+			return 0, err
+		}
+	}
+	lintDiags, err := lint.File(path, lint.Options{Config: config})
+	if err != nil {
+		return 0, err
+	}
+	diags = append(diags, lintDiags...)
+	diag.Sort(diags)
+
+	errorCount := diag.Count(diags, diag.SeverityError)
+	if len(diags) == 0 {
+		fmt.Println("✅ Validation, lint and secret scan passed")
+		return 0, nil
+	}
+	fmt.Printf("🔎 Validation, lint and secret scan found %d error(s), %d warning(s), %d info:\n",
+		errorCount, diag.Count(diags, diag.SeverityWarning), diag.Count(diags, diag.SeverityInfo))
+	if err := diag.Write(os.Stdout, "text", "promptbucket", nil, diags); err != nil {
+		return 0, err
+	}
+	return errorCount, nil
+}
+
+// printPublishedDiff shows how a manifest differs from the latest published
+// version of the package, if there is one.
+func printPublishedDiff(ctx context.Context, client *registry.Client, published *registry.Package, manifestData []byte) error {
+	if published == nil || len(published.Versions) == 0 {
+		fmt.Println("\n🆕 Nothing published yet; this would be the first version")
+		return nil
+	}
+	latest, err := registry.Resolve(published, "")
+	if err != nil {
+		fmt.Printf("\n⚠️  Not comparing with a published version: %v\n", err)
+		return nil
+	}
+	old, err := client.Manifest(ctx, published.Org, published.Name, latest.Tag)
+	if err != nil {
+		return fmt.Errorf("failed to download %s:%s for comparison: %w", published.FullName(), latest.Tag, err)
+	}
+	changes, err := packager.DiffManifests(old, manifestData)
+	if err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		fmt.Printf("\n🔍 No changes since %s:%s\n", published.FullName(), latest.Tag)
+		return nil
+	}
+	fmt.Printf("\n🔍 Changes since %s:%s:\n", published.FullName(), latest.Tag)
+	for _, c := range changes {
+		switch {
+		case c.Added():
+			printChangedValue("+", c.Field, c.New)
+		case c.Removed():
+			printChangedValue("-", c.Field, c.Old)
+		case strings.Contains(c.Old, "\n") || strings.Contains(c.New, "\n"):
+			fmt.Printf("  ~ %s:\n", c.Field)
+			for _, line := range packager.LineDiff(c.Old, c.New) {
+				if !strings.HasPrefix(line, "  ") {
+					fmt.Printf("      %s\n", line)
+				}
+			}
+		default:
+			fmt.Printf("  ~ %s: %s → %s\n", c.Field, c.Old, c.New)
+		}
+	}
+	return nil
+}
+
+// printChangedValue prints an added or removed field, putting multi-line
+// values on lines of their own.
+func printChangedValue(marker, field, value string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Printf("  %s %s: %s\n", marker, field, value)
+		return
+	}
+	fmt.Printf("  %s %s:\n", marker, field)
+	for _, line := range strings.Split(strings.TrimRight(value, "\n"), "\n") {
+		fmt.Printf("      %s\n", line)
+	}
+}
+
+// printRequest prints an HTTP request as it would go over the wire, with
+// credentials redacted.
+func printRequest(req *http.Request, body []byte) {
+	fmt.Println("\n📨 Request that would be sent:")
+	fmt.Printf("   %s %s\n", req.Method, req.URL)
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			if name == "Authorization" {
+				scheme, _, _ := strings.Cut(value, " ")
+				value = scheme + " <redacted>"
+			}
+			fmt.Printf("   %s: %s\n", name, value)
+		}
+	}
+	fmt.Printf("   Content-Length: %d\n\n", len(body))
+	for _, line := range strings.Split(strings.TrimRight(string(body), "\n"), "\n") {
+		fmt.Printf("   %s\n", line)
+	}
+}
+
+// resolveNamespace returns the namespace to publish under: the requested
+// org if the user may publish to it, otherwise their own namespace from the
+// registry profile. Registries without a profile endpoint fall back to the
+// part of the email before "@".
+func resolveNamespace(ctx context.Context, client *registry.Client, user *auth.Token, requested string) (string, error) {
+	profile, err := client.Profile(ctx)
+	if registry.IsNotFound(err) {
+		own := user.Email
+		if idx := strings.Index(own, "@"); idx != -1 {
+			own = own[:idx]
+		}
+		if requested != "" && requested != own {
+			fmt.Printf("⚠️  The registry has no user profile; pushing to %s without checking membership\n", requested)
+			return requested, nil
+		}
+		return own, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user profile: %w", err)
+	}
+	if requested == "" || requested == profile.Username {
+		return profile.Username, nil
+	}
+
+	switch role := profile.Role(requested); {
+	case role == "":
+		return "", fmt.Errorf("you are not a member of org %s; ask one of its owners or admins to run 'promptbucket org members add %s %s --role writer'", requested, requested, profile.Username)
+	case !registry.CanPublish(role):
+		return "", fmt.Errorf("your role in org %s is %s, which cannot publish; ask an owner or admin to run 'promptbucket org members role %s %s writer'", requested, role, requested, profile.Username)
+	}
+	return requested, nil
+}
+
+// checkVersionPolicy refuses to push a version that was already published or
+// that is lower than the highest published version. pkg is nil on first
+// publish.
+func checkVersionPolicy(pkg *registry.Package, version semver.Version) error {
+	if pkg == nil {
+		return nil
+	}
+
+	var parsed []semver.Version
+	for _, published := range pkg.Versions {
+		v, err := semver.Parse(published.Tag)
+		if err != nil {
+			// Versions published before semver was enforced cannot be ordered
+			continue
+		}
+		if v.Compare(version) == 0 {
+			return fmt.Errorf("version %s of %s was already published as %s; bump the version in %s", version, pkg.FullName(), published.Tag, packager.ManifestFile)
+		}
+		parsed = append(parsed, v)
+	}
+
+	if latest, ok := semver.Max(parsed); ok && version.Less(latest) {
+		return fmt.Errorf("version %s is lower than the latest published version %s of %s", version, latest, pkg.FullName())
+	}
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(pushCmd)
 	pushCmd.Flags().Bool("build", false, "Also build a local .promptbucket archive file")
+	pushCmd.Flags().Bool("dry-run", false, "Validate and preview the push without uploading anything")
+	pushCmd.Flags().String("tag", "", "Dist-tag to point at the version (e.g. beta)")
+	pushCmd.Flags().String("namespace", "", "Org to publish under instead of your own namespace")
+	pushCmd.Flags().String("visibility", "", "Who can see the package: private, org or public (default: keep the current visibility)")
 }
\ No newline at end of file
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/promptbucket/cli/internal/auth"
+	"github.com/promptbucket/cli/internal/registry"
+)
+
+// packageRef identifies a package in the registry, optionally at an exact
+// version, a semver range or a dist-tag to resolve against the published
+// versions. A reference may name the registry to use, as
+// registry:org/name.
+type packageRef struct {
+	Registry string
+	Org      string
+	Name     string
+	Version  string
+	// Constraint is a range such as "^1.2" given as org/name@range.
+	Constraint string
+	// DistTag is a dist-tag such as "beta" given as org/name:@tag.
+	DistTag string
+}
+
+// resolvable returns what to pass to registry.Resolve for a reference
+// without an exact version.
+func (r packageRef) resolvable() string {
+	if r.DistTag != "" {
+		return "@" + r.DistTag
+	}
+	return r.Constraint
+}
+
+func (r packageRef) String() string {
+	if r.Registry != "" {
+		unqualified := r
+		unqualified.Registry = ""
+		return r.Registry + ":" + unqualified.String()
+	}
+	switch {
+	case r.Version != "":
+		return fmt.Sprintf("%s/%s:%s", r.Org, r.Name, r.Version)
+	case r.DistTag != "":
+		return fmt.Sprintf("%s/%s:@%s", r.Org, r.Name, r.DistTag)
+	case r.Constraint != "":
+		return fmt.Sprintf("%s/%s@%s", r.Org, r.Name, r.Constraint)
+	}
+	return r.Org + "/" + r.Name
+}
+
+// config returns the config for the registry the package is in: the one
+// the reference names, else the one routing picks.
+func (r packageRef) config() (*auth.Config, error) {
+	if r.Registry != "" {
+		return auth.ConfigForRegistry(r.Registry)
+	}
+	return auth.ConfigForPackage(r.Org, r.Name), nil
+}
+
+// client returns a registry client for the registry the package is in.
+func (r packageRef) client() (*registry.Client, error) {
+	config, err := r.config()
+	if err != nil {
+		return nil, err
+	}
+	return registry.NewFromConfig(config), nil
+}
+
+// parsePackageRef parses "org/name", "org/name:version", "org/name:@tag" or
+// "org/name@range", optionally prefixed with "registry:".
+func parsePackageRef(spec string) (packageRef, error) {
+	var ref packageRef
+
+	// Check for a registry before the org
+	if colon, slash := strings.Index(spec, ":"), strings.Index(spec, "/"); colon >= 0 && colon < slash {
+		if colon == 0 {
+			return ref, fmt.Errorf("invalid package format: %s (expected registry:org/name)", spec)
+		}
+		ref.Registry = spec[:colon]
+		spec = spec[colon+1:]
+	}
+
+	parts := strings.Split(spec, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return ref, fmt.Errorf("invalid package format: %s (expected org/name[:version], org/name:@tag or org/name@range)", spec)
+	}
+	ref.Org = parts[0]
+	ref.Name = parts[1]
+
+	// Check for a dist-tag
+	if idx := strings.Index(ref.Name, ":@"); idx >= 0 {
+		ref.DistTag = ref.Name[idx+2:]
+		ref.Name = ref.Name[:idx]
+		if ref.Name == "" {
+			return ref, fmt.Errorf("invalid package format: %s (expected org/name:@tag)", spec)
+		}
+		return ref, registry.CheckDistTag(ref.DistTag)
+	}
+
+	// Check for a range in the package name part
+	if idx := strings.Index(ref.Name, "@"); idx >= 0 {
+		ref.Constraint = strings.TrimSpace(ref.Name[idx+1:])
+		ref.Name = ref.Name[:idx]
+		if ref.Name == "" || ref.Constraint == "" {
+			return ref, fmt.Errorf("invalid package format: %s (expected org/name@range)", spec)
+		}
+		return ref, nil
+	}
+
+	// Check for version in the package name part
+	if idx := strings.LastIndex(ref.Name, ":"); idx > 0 {
+		ref.Version = ref.Name[idx+1:]
+		ref.Name = ref.Name[:idx]
+	}
+	return ref, nil
+}
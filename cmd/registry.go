@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/promptbucket/cli/internal/auth"
+	"github.com/promptbucket/cli/internal/registry/server"
+	"github.com/spf13/cobra"
+)
+
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Work with the registries packages come from",
+	Long: `The default registry is at $PROMPTBUCKET_BASE_URL. Others are listed under
+registries in ~/.promptbucket/config.yaml:
+
+  registries:
+    - name: internal
+      url: https://prompts.internal.example
+      scopes: ["acme/*"]
+      token_env: INTERNAL_PROMPTBUCKET_TOKEN
+
+A package goes to the first registry with a scope matching its org/name, and
+to the default registry otherwise. --registry name sends every request to one
+registry, and a reference such as internal:acme/reviewer:1.0.0 names the
+registry for that package. Log in to each registry with
+'promptbucket login --registry name', or set the variable named by token_env.`,
+}
+
+var registryListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List configured registries and their scopes",
+	Long: `List configured registries and their scopes. The registry marked * gets
+packages that no scope matches, or every package with --registry.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		selected := auth.NewConfig().Registry
+		registries, err := auth.NewConfig().Registries()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tURL\tSCOPES\tLOGGED IN")
+		for _, r := range registries {
+			name := r.Name
+			if r.Name == selected {
+				name += " *"
+			}
+			scopes := strings.Join(r.Scopes, ", ")
+			if scopes == "" {
+				scopes = "-"
+			}
+			config, err := auth.ConfigForRegistry(r.Name)
+			if err != nil {
+				return err
+			}
+			login := "no"
+			if token, err := auth.NewTokenManager(config).GetToken(); err == nil && token != nil && token.AccessToken != "" {
+				login = "yes"
+				if token.Email != "" {
+					login = token.Email
+				}
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, r.URL, scopes, login)
+		}
+		return w.Flush()
+	},
+}
+
+var registryServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run a registry that keeps packages in a directory",
+	Long: `Run a registry that keeps packages in a directory, for teams that want a
+private registry without the hosted service. It serves search, popular,
+trending, package info, stars, pull and push; org management, dist-tag,
+deprecate, visibility and delete commands are not supported.
+
+Anyone can read public packages. Users are listed in users.yaml in the root
+directory, and push and star with their token:
+
+  users:
+    - username: alice
+      email: alice@example.com
+      token: <a long random string>
+      orgs: {acme: writer}
+
+Point the CLI at the server with PROMPTBUCKET_BASE_URL, or add it under
+registries in ~/.promptbucket/config.yaml with token_env naming a variable
+that holds the token.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, _ := cmd.Flags().GetString("root")
+		addr, _ := cmd.Flags().GetString("addr")
+		cmd.SilenceUsage = true
+
+		if err := os.MkdirAll(root, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", root, err)
+		}
+		users, err := server.LoadUsers(filepath.Join(root, server.UsersFile))
+		if err != nil {
+			return err
+		}
+
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", addr, err)
+		}
+		srv := &http.Server{
+			Handler:           logRequests(server.New(&server.Store{Root: root}, users)),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		fmt.Printf("📦 Serving %s at http://%s\n", root, listener.Addr())
+		if len(users) == 0 {
+			fmt.Printf("⚠️  No users in %s: packages can be read but not pushed or starred\n", filepath.Join(root, server.UsersFile))
+		} else {
+			fmt.Printf("👤 %d user(s) loaded\n", len(users))
+		}
+
+		errc := make(chan error, 1)
+		go func() { errc <- srv.Serve(listener) }()
+
+		select {
+		case err := <-errc:
+			return fmt.Errorf("server failed: %w", err)
+		case <-cmd.Context().Done():
+		}
+
+		// Let requests in flight finish within the grace period Ctrl-C gives
+		ctx, cancel := context.WithTimeout(context.Background(), interruptGrace)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("failed to stop server: %w", err)
+		}
+		return nil
+	},
+}
+
+// statusRecorder remembers the status a handler writes.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// logRequests prints a line for each request the server handles.
+func logRequests(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(rec, r)
+		fmt.Printf("%s %s %s %d %s\n", start.Format(time.TimeOnly), r.Method, r.URL.Path, rec.status, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(registryCmd)
+	registryCmd.AddCommand(registryListCmd)
+	registryCmd.AddCommand(registryServeCmd)
+
+	registryServeCmd.Flags().String("root", "data", "Directory to keep packages in")
+	registryServeCmd.Flags().String("addr", "127.0.0.1:8080", "Address to listen on")
+}
@@ -1,16 +1,23 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
+	"github.com/promptbucket/cli/internal/auth"
+	"github.com/promptbucket/cli/internal/cache"
+	"github.com/promptbucket/cli/internal/version"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/subosito/gotenv"
 )
 
-var version = "dev"
-
 var rootCmd = &cobra.Command{
 	Use:   "promptbucket",
 	Short: "PromptBucket CLI",
@@ -19,16 +26,71 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+// interruptGrace is how long a command has to stop after an interrupt
+// before the CLI exits anyway, for commands blocked on something other than
+// a request, such as a prompt for input.
+const interruptGrace = 2 * time.Second
+
+// Execute runs the CLI with a context that is cancelled on SIGINT or SIGTERM,
+// aborting requests in progress. A second signal exits immediately.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+		time.Sleep(interruptGrace)
+		exitInterrupted()
+	}()
+
+	// Report an interrupt once, rather than as the command's error with its
+	// usage
+	rootCmd.SetErr(quietWhenDone{ctx: ctx, w: os.Stderr})
+	usage := rootCmd.UsageFunc()
+	rootCmd.SetUsageFunc(func(c *cobra.Command) error {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return usage(c)
+	})
+
+	err := rootCmd.ExecuteContext(ctx)
+	if ctx.Err() != nil {
+		exitInterrupted()
+	}
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
+// exitInterrupted exits with the status a shell gives a process killed by
+// SIGINT.
+func exitInterrupted() {
+	fmt.Fprintln(os.Stderr, "\n⚠️  Interrupted")
+	os.Exit(130)
+}
+
+// quietWhenDone writes to w until ctx is done, then discards output.
+type quietWhenDone struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (q quietWhenDone) Write(p []byte) (int, error) {
+	if q.ctx.Err() != nil {
+		return len(p), nil
+	}
+	return q.w.Write(p)
+}
+
 func init() {
 	rootCmd.PersistentFlags().BoolP("version", "v", false, "print version")
 	viper.BindPFlag("version", rootCmd.PersistentFlags().Lookup("version"))
+	rootCmd.PersistentFlags().Bool("offline", false, "Use only the local cache; fail instead of using the network (or set PROMPTBUCKET_OFFLINE=1)")
+	rootCmd.PersistentFlags().Int("retries", auth.DefaultMaxRetries, "Times to retry a failed registry request (or set PROMPTBUCKET_RETRIES)")
+	rootCmd.PersistentFlags().String("registry", "", "Registry to use instead of routing by scope, by name from the config file (or set PROMPTBUCKET_REGISTRY)")
+	rootCmd.PersistentFlags().Duration("timeout", auth.DefaultTimeout, "Timeout for each registry request attempt, e.g. 45s (or set PROMPTBUCKET_TIMEOUT)")
 	
 	// Load .env files before setting up viper
 	loadEnvFiles()
@@ -36,12 +98,92 @@ func init() {
 	// Setup viper after .env files are loaded
 	viper.AutomaticEnv()
 	
-	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
 		if viper.GetBool("version") {
-			fmt.Println(version)
+			fmt.Println(version.Version)
 			os.Exit(0)
 		}
+		offline, _ := cmd.Flags().GetBool("offline")
+		if env := os.Getenv("PROMPTBUCKET_OFFLINE"); env != "" && env != "0" && env != "false" {
+			offline = true
+		}
+		cache.SetOffline(offline)
+		if err := configureNetwork(); err != nil {
+			return err
+		}
+		if err := configureRegistry(cmd); err != nil {
+			return err
+		}
+		return configureRequests(cmd)
+	}
+}
+
+// configureRequests applies --retries and --timeout, falling back to
+// PROMPTBUCKET_RETRIES and PROMPTBUCKET_TIMEOUT when the flags are not given.
+func configureRequests(cmd *cobra.Command) error {
+	retries, _ := cmd.Flags().GetInt("retries")
+	if env := os.Getenv("PROMPTBUCKET_RETRIES"); env != "" && !cmd.Flags().Changed("retries") {
+		n, err := strconv.Atoi(env)
+		if err != nil {
+			return fmt.Errorf("invalid PROMPTBUCKET_RETRIES %q: expected a number", env)
+		}
+		retries = n
+	}
+	if retries < 0 {
+		return fmt.Errorf("retries must not be negative")
+	}
+
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	if env := os.Getenv("PROMPTBUCKET_TIMEOUT"); env != "" && !cmd.Flags().Changed("timeout") {
+		d, err := time.ParseDuration(env)
+		if err != nil {
+			// Plain numbers are seconds
+			n, numErr := strconv.Atoi(env)
+			if numErr != nil {
+				return fmt.Errorf("invalid PROMPTBUCKET_TIMEOUT %q: expected a duration such as 45s", env)
+			}
+			d = time.Duration(n) * time.Second
+		}
+		timeout = d
+	}
+	if timeout <= 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+
+	auth.SetRetries(retries)
+	auth.SetTimeout(timeout)
+	return nil
+}
+
+// configureNetwork checks the proxy and TLS settings and builds the one
+// transport that API requests and downloads share.
+func configureNetwork() error {
+	network, err := auth.NewConfig().Network()
+	if err != nil {
+		return err
+	}
+	transport, err := network.Transport()
+	if err != nil {
+		return fmt.Errorf("invalid network settings: %w", err)
+	}
+	if network.InsecureSkipVerify {
+		fmt.Fprintln(os.Stderr, "⚠️  TLS certificate verification is disabled (insecure_skip_verify)")
+	}
+	auth.SetTransport(transport)
+	return nil
+}
+
+// configureRegistry checks the configured registries and selects the one
+// named by --registry or PROMPTBUCKET_REGISTRY, if any.
+func configureRegistry(cmd *cobra.Command) error {
+	if _, err := auth.NewConfig().Registries(); err != nil {
+		return err
+	}
+	name, _ := cmd.Flags().GetString("registry")
+	if env := os.Getenv("PROMPTBUCKET_REGISTRY"); env != "" && !cmd.Flags().Changed("registry") {
+		name = env
 	}
+	return auth.SetRegistry(name)
 }
 
 // loadEnvFiles loads environment variables from .env files in order of precedence
@@ -1,11 +1,13 @@
 package cmd
 
 import (
+    "context"
     "fmt"
     "os"
     "os/exec"
     "strings"
 
+    "github.com/promptbucket/cli/internal/install"
     "github.com/promptbucket/cli/internal/packager"
     "github.com/spf13/cobra"
 )
@@ -17,34 +19,76 @@ var (
 )
 
 var runCmd = &cobra.Command{
-    Use:   "run",
+    Use:   "run [org/name]",
     Short: "Build prompt with variable substitution and optionally pipe to tool",
+    Long: `Render promptbucket.yaml with variable substitution and optionally pipe the
+prompt to a tool.
+
+Given org/name, render that package from .promptbucket/packages instead; add it
+to dependencies and run 'promptbucket install' first.`,
+    Args: cobra.MaximumNArgs(1),
     RunE: func(cmd *cobra.Command, args []string) error {
+        // Render an installed package
+        if len(args) == 1 {
+            return runInstalledPackage(cmd.Context(), args[0])
+        }
+        
         // Build with variables
-        if err := packager.BuildWithVariables(runVarFlags); err != nil {
+        if err := packager.BuildWithVariables(cmd.Context(), runVarFlags); err != nil {
             return err
         }
         
         // If tool is specified, pipe the prompt to it
         if runToolFlag != "" {
-            return runWithToolIntegration(runToolFlag)
+            return runWithToolIntegration(cmd.Context(), runToolFlag)
         }
         
         return nil
     },
 }
 
-func runWithToolIntegration(toolName string) error {
-    adapter, exists := Adapters[toolName]
-    if !exists {
-        return fmt.Errorf("unsupported tool: %s", toolName)
+// runInstalledPackage renders a package installed by 'promptbucket install'
+func runInstalledPackage(ctx context.Context, spec string) error {
+    ref, err := parsePackageRef(spec)
+    if err != nil {
+        return err
+    }
+    if ref.Version != "" || ref.Constraint != "" || ref.DistTag != "" {
+        return fmt.Errorf("run uses the installed version of %s/%s; change the range in dependencies and run 'promptbucket install'", ref.Org, ref.Name)
+    }
+    
+    path := install.ManifestPath(install.Dir, ref.Org, ref.Name)
+    if _, err := os.Stat(path); err != nil {
+        return fmt.Errorf("%s is not installed; add it to dependencies and run 'promptbucket install'", ref)
+    }
+    
+    filename, err := packager.BuildPackageWithVariables(ctx, path, runVarFlags)
+    if err != nil {
+        return err
     }
     
+    // If tool is specified, pipe the prompt to it
+    if runToolFlag != "" {
+        return pipePromptToTool(runToolFlag, filename)
+    }
+    return nil
+}
+
+func runWithToolIntegration(ctx context.Context, toolName string) error {
     // Get the expected prompt filename
-    filename, err := packager.GetPromptFilename(packager.ManifestFile)
+    filename, err := packager.GetPromptFilename(ctx, packager.ManifestFile)
     if err != nil {
         return fmt.Errorf("failed to get prompt filename: %w", err)
     }
+    return pipePromptToTool(toolName, filename)
+}
+
+// pipePromptToTool runs a tool adapter with a rendered prompt file on stdin
+func pipePromptToTool(toolName, filename string) error {
+    adapter, exists := Adapters[toolName]
+    if !exists {
+        return fmt.Errorf("unsupported tool: %s", toolName)
+    }
     
     // Read the prompt file
     content, err := os.ReadFile(filename)
@@ -1,12 +1,12 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"strings"
 
 	"github.com/promptbucket/cli/internal/auth"
+	"github.com/promptbucket/cli/internal/registry"
 	"github.com/spf13/cobra"
 )
 
@@ -15,136 +15,93 @@ var searchCmd = &cobra.Command{
 	Short: "Search for packages in the registry",
 	Long: `Search for packages in the PromptBucket registry using keywords.
 
-The search looks through package names, descriptions, tags, and authors.`,
-	Args: cobra.MinimumNArgs(1),
+The search looks through package names, descriptions, tags, and authors.
+Filters narrow the results and may be used without a query:
+
+  promptbucket search review --language go --sort stars
+  promptbucket search --tag security --tag python --licence MIT`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		query := strings.Join(args, " ")
-		limit, _ := cmd.Flags().GetInt("limit")
-
-		// Create auth config and API client
-		config := auth.NewConfig()
-		apiClient := auth.NewAPIClient(config)
-
-		// Build endpoint
-		endpoint := fmt.Sprintf("/packages/search?q=%s", query)
-		if limit > 0 {
-			endpoint = fmt.Sprintf("%s&limit=%d", endpoint, limit)
+		if query == "" && !hasSearchFilters(cmd) {
+			return fmt.Errorf("specify a search query or at least one filter")
 		}
-
-		// Search packages
-		fmt.Printf("🔍 Searching for \"%s\"...\n", query)
-		resp, err := apiClient.Get(endpoint)
+		req, err := searchRequest(cmd, query)
 		if err != nil {
-			return fmt.Errorf("search failed: %w", err)
-		}
-		defer resp.Body.Close()
-
-		// Read response
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("failed to read response: %w", err)
-		}
-
-		// Check status
-		if resp.StatusCode != 200 {
-			var errorResp map[string]interface{}
-			if err := json.Unmarshal(body, &errorResp); err == nil {
-				if msg, ok := errorResp["message"].(string); ok {
-					return fmt.Errorf("search failed: %s", msg)
-				}
-				if errMsg, ok := errorResp["error"].(string); ok {
-					return fmt.Errorf("search failed: %s", errMsg)
-				}
-			}
-			return fmt.Errorf("search failed with status %d: %s", resp.StatusCode, string(body))
+			return err
 		}
 
-		// Parse results - the API returns an object with results array
-		var searchResp struct {
-			Query   string                   `json:"query"`
-			Results []map[string]interface{} `json:"results"`
-			Total   int                      `json:"total"`
-			Limit   int                      `json:"limit"`
-			Offset  int                      `json:"offset"`
-		}
-		
-		if err := json.Unmarshal(body, &searchResp); err != nil {
-			return fmt.Errorf("failed to parse response: %w", err)
-		}
+		// Create registry client
+		client := registry.NewFromConfig(auth.NewConfig())
 
-		// Display results
-		if searchResp.Total == 0 {
-			fmt.Printf("No packages found matching \"%s\".\n", query)
-			fmt.Println("\nTry:")
-			fmt.Println("  • Using different keywords")
-			fmt.Println("  • Browsing all packages with 'promptbucket list'")
-			return nil
+		// Search packages
+		if query != "" {
+			fmt.Printf("🔍 Searching for \"%s\"...\n", query)
+		} else {
+			fmt.Println("🔍 Searching packages...")
 		}
-
-		fmt.Printf("\nFound %d result(s):\n\n", searchResp.Total)
-		
-		for i, result := range searchResp.Results {
-			// Extract fields
-			name, _ := result["name"].(string)
-			orgID, _ := result["org_id"].(string)
-			org, _ := result["org"].(string)
-			description, _ := result["description"].(string)
-			
-			// Display result number and name
-			fmt.Printf("%d. 📦 ", i+1)
-			if org != "" && name != "" {
-				fmt.Printf("%s/%s", org, name)
-			} else if orgID != "" && name != "" {
-				fmt.Printf("%s/%s", orgID, name)
-			} else if name != "" {
-				fmt.Printf("%s", name)
-			}
-			
-			// Show stats if available
-			if starCount, ok := getFloat(result["star_count"]); ok && starCount > 0 {
-				fmt.Printf(" ⭐ %.0f", starCount)
-			}
-			if pullCount, ok := getFloat(result["pull_count"]); ok && pullCount > 0 {
-				fmt.Printf(" 📥 %.0f", pullCount)
-			}
-			
-			fmt.Println()
-			
-			// Show description
-			if description != "" {
-				fmt.Printf("   %s\n", description)
-			}
-			
-			// Show tags if available
-			if tags, ok := result["tags"].([]interface{}); ok && len(tags) > 0 {
-				tagStrings := make([]string, 0, len(tags))
-				for _, tag := range tags {
-					if tagStr, ok := tag.(string); ok {
-						tagStrings = append(tagStrings, tagStr)
-					}
+		err = printListing(cmd, listing{
+			list: func(ctx context.Context, opts registry.ListOptions) (*registry.PackageList, error) {
+				req.ListOptions = opts
+				return client.Search(ctx, req)
+			},
+			header: func(total int) { fmt.Printf("\nFound %d result(s):\n\n", total) },
+			empty: func() {
+				if query != "" {
+					fmt.Printf("No packages found matching \"%s\".\n", query)
+				} else {
+					fmt.Println("No packages found matching the filters.")
 				}
-				if len(tagStrings) > 0 {
-					fmt.Printf("   Tags: %s\n", strings.Join(tagStrings, ", "))
-				}
-			}
-			
-			// Show how to get more info
-			if org != "" && name != "" {
-				fmt.Printf("   Info: promptbucket info %s/%s\n", org, name)
-			} else if name != "" {
-				fmt.Printf("   Info: promptbucket info %s\n", name)
-			}
-			
-			fmt.Println()
+				fmt.Println("\nTry:")
+				fmt.Println("  • Using different keywords")
+				fmt.Println("  • Browsing all packages with 'promptbucket list'")
+			},
+			item: printSearchResult,
+		})
+		if err != nil {
+			return fmt.Errorf("search failed: %w", err)
 		}
-
 		return nil
 	},
 }
 
-// getFloat is defined in list.go, so we don't need to redefine it here
+func printSearchResult(n int, pkg registry.Package) {
+	// Display result number and name
+	fmt.Printf("%d. 📦 %s", n, pkg.FullName())
+	
+	// Show visibility, stats if available
+	if label := visibilityLabel(pkg.Visibility); label != "" {
+		fmt.Printf(" %s", label)
+	}
+	if pkg.StarCount > 0 {
+		fmt.Printf(" ⭐ %d", pkg.StarCount)
+	}
+	if pkg.PullCount > 0 {
+		fmt.Printf(" 📥 %d", pkg.PullCount)
+	}
+	
+	fmt.Println()
+	
+	// Show description
+	if pkg.Description != "" {
+		fmt.Printf("   %s\n", pkg.Description)
+	}
+	
+	// Show tags if available
+	if len(pkg.Tags) > 0 {
+		fmt.Printf("   Tags: %s\n", strings.Join(pkg.Tags, ", "))
+	}
+	
+	// Show how to get more info
+	if pkg.Name != "" {
+		fmt.Printf("   Info: promptbucket info %s\n", pkg.FullName())
+	}
+	
+	fmt.Println()
+}
 
 func init() {
 	rootCmd.AddCommand(searchCmd)
-	searchCmd.Flags().Int("limit", 10, "Maximum number of results to display")
+	searchCmd.Flags().Int("limit", 10, "Number of results per page")
+	addPagingFlags(searchCmd)
+	addSearchFilterFlags(searchCmd)
 }
\ No newline at end of file
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/promptbucket/cli/internal/secrets"
+)
+
+// loadSecretsAllowlist loads the allowlist that applies to manifestPath, if any.
+func loadSecretsAllowlist(manifestPath string) (*secrets.Allowlist, error) {
+	path := secrets.FindAllowlist(manifestPath)
+	if path == "" {
+		return nil, nil
+	}
+	return secrets.LoadAllowlist(path)
+}
+
+// scanPackage scans files for credentials and personal data, dropping
+// findings covered by the allowlist that applies to manifestPath.
+func scanPackage(manifestPath string, files []string) ([]secrets.Finding, error) {
+	allowlist, err := loadSecretsAllowlist(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	return secrets.ScanFiles(files, allowlist)
+}
+
+// scanSource is scanPackage for content that is not a local file, such as a
+// manifest inside an archive or fetched from the registry.
+func scanSource(manifestPath, name string, data []byte) ([]secrets.Finding, error) {
+	allowlist, err := loadSecretsAllowlist(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	var findings []secrets.Finding
+	for _, f := range secrets.Scan(name, data) {
+		if !allowlist.Allows(f) {
+			findings = append(findings, f)
+		}
+	}
+	return findings, nil
+}
+
+// requireNoSecrets blocks action when files contain findings that have not
+// been explicitly allowlisted.
+func requireNoSecrets(action, manifestPath string, files []string) error {
+	findings, err := scanPackage(manifestPath, files)
+	if err != nil {
+		return err
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+
+	fmt.Printf("🔒 Secret scan found %d potential issue(s):\n", len(findings))
+	for _, f := range findings {
+		d := f.Diagnostic()
+		fmt.Printf("  - %s:%d:%d %s [%s]\n", d.File, d.Line, d.Column, d.Message, d.Rule)
+	}
+	fmt.Printf("\nIf these values are safe to publish, allowlist their fingerprints in %s.\n", secrets.AllowlistFile)
+	return fmt.Errorf("%s blocked by secret scan", action)
+}
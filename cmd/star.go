@@ -1,13 +1,11 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
-	"net/http"
-	"strings"
 
 	"github.com/promptbucket/cli/internal/auth"
+	"github.com/promptbucket/cli/internal/registry"
 	"github.com/spf13/cobra"
 )
 
@@ -20,7 +18,7 @@ Package can be specified as:
   - org/name (e.g., rawte.mayur/Ui-Artist)`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return handleStar(args[0], true)
+		return handleStar(cmd.Context(), args[0], true)
 	},
 }
 
@@ -33,72 +31,48 @@ Package can be specified as:
   - org/name (e.g., rawte.mayur/Ui-Artist)`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return handleStar(args[0], false)
+		return handleStar(cmd.Context(), args[0], false)
 	},
 }
 
-func handleStar(packageSpec string, star bool) error {
+func handleStar(ctx context.Context, packageSpec string, star bool) error {
 	// Parse package specification
-	parts := strings.Split(packageSpec, "/")
-	if len(parts) != 2 {
+	ref, err := parsePackageRef(packageSpec)
+	if err != nil {
+		return err
+	}
+	if ref.Version != "" || ref.Constraint != "" || ref.DistTag != "" {
 		return fmt.Errorf("invalid package format: %s (expected org/name)", packageSpec)
 	}
-	org := parts[0]
-	packageName := parts[1]
+	org := ref.Org
+	packageName := ref.Name
 
 	// Create auth config and API client
-	config := auth.NewConfig()
+	config, err := ref.config()
+	if err != nil {
+		return err
+	}
 	apiClient := auth.NewAPIClient(config)
 
 	// Check if authenticated
 	if !apiClient.IsAuthenticated() {
-		return fmt.Errorf("authentication required. Run 'promptbucket login' first")
+		return fmt.Errorf("authentication required. Run '%s' first", config.LoginCommand())
 	}
-
-	// Build endpoint
-	endpoint := fmt.Sprintf("/packages/%s/%s/star", org, packageName)
+	client := registry.New(apiClient)
 
 	// Make request
-	var resp *http.Response
-	var err error
-	
 	if star {
 		fmt.Printf("⭐ Starring %s/%s...\n", org, packageName)
-		resp, err = apiClient.Post(endpoint, nil)
+		err = client.Star(ctx, org, packageName)
 	} else {
 		fmt.Printf("💫 Unstarring %s/%s...\n", org, packageName)
-		resp, err = apiClient.Delete(endpoint)
+		err = client.Unstar(ctx, org, packageName)
 	}
-	
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read response
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// Check status
-	if resp.StatusCode == 404 {
+	if registry.IsNotFound(err) {
 		return fmt.Errorf("package not found: %s/%s", org, packageName)
 	}
-	if resp.StatusCode == 401 {
-		return fmt.Errorf("authentication required. Run 'promptbucket login' first")
-	}
-	if resp.StatusCode != 200 && resp.StatusCode != 201 && resp.StatusCode != 204 {
-		var errorResp map[string]interface{}
-		if err := json.Unmarshal(body, &errorResp); err == nil {
-			if msg, ok := errorResp["message"].(string); ok {
-				return fmt.Errorf("%s", msg)
-			}
-			if errMsg, ok := errorResp["error"].(string); ok {
-				return fmt.Errorf("%s", errMsg)
-			}
-		}
-		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+	if err != nil {
+		return err
 	}
 
 	// Success
@@ -122,92 +96,56 @@ var starsCmd = &cobra.Command{
 
 		// Check if authenticated
 		if !apiClient.IsAuthenticated() {
-			return fmt.Errorf("authentication required. Run 'promptbucket login' first")
+			return fmt.Errorf("authentication required. Run '%s' first", config.LoginCommand())
 		}
 
 		// Get starred packages
 		fmt.Println("⭐ Fetching your starred packages...")
-		resp, err := apiClient.Get("/user/starred")
+		err := printListing(cmd, listing{
+			list:   registry.New(apiClient).Starred,
+			header: func(total int) { fmt.Printf("\n⭐ Your starred packages (%d):\n\n", total) },
+			empty: func() {
+				fmt.Println("\nYou haven't starred any packages yet.")
+				fmt.Println("\nStar a package with:")
+				fmt.Println("  promptbucket star org/package")
+			},
+			item: printStarredPackage,
+		})
 		if err != nil {
 			return fmt.Errorf("failed to fetch starred packages: %w", err)
 		}
-		defer resp.Body.Close()
-
-		// Read response
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("failed to read response: %w", err)
-		}
-
-		// Check status
-		if resp.StatusCode == 401 {
-			return fmt.Errorf("authentication required. Run 'promptbucket login' first")
-		}
-		if resp.StatusCode != 200 {
-			return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
-		}
-
-		// Parse response - API returns object with results array
-		var response struct {
-			Results []map[string]interface{} `json:"results"`
-			Total   int                      `json:"total"`
-		}
-		if err := json.Unmarshal(body, &response); err != nil {
-			return fmt.Errorf("failed to parse response: %w", err)
-		}
-
-		packages := response.Results
-
-		// Display results
-		if len(packages) == 0 {
-			fmt.Println("\nYou haven't starred any packages yet.")
-			fmt.Println("\nStar a package with:")
-			fmt.Println("  promptbucket star org/package")
-			return nil
-		}
-
-		fmt.Printf("\n⭐ Your starred packages (%d):\n\n", len(packages))
-		
-		for i, pkg := range packages {
-			// Extract package info
-			name, _ := pkg["name"].(string)
-			org, _ := pkg["org"].(string)
-			description, _ := pkg["description"].(string)
-			
-			// Display package
-			fmt.Printf("%d. 📦 ", i+1)
-			if org != "" && name != "" {
-				fmt.Printf("%s/%s", org, name)
-			} else if name != "" {
-				fmt.Printf("%s", name)
-			}
-			
-			// Show pull count if available
-			if pullCount, ok := getFloatValue(pkg["pull_count"]); ok && pullCount > 0 {
-				fmt.Printf(" 📥 %.0f", pullCount)
-			}
-			
-			fmt.Println()
-			
-			// Show description
-			if description != "" {
-				fmt.Printf("   %s\n", description)
-			}
-			
-			// Show how to unstar
-			if org != "" && name != "" {
-				fmt.Printf("   Unstar: promptbucket unstar %s/%s\n", org, name)
-			}
-			
-			fmt.Println()
-		}
-
 		return nil
 	},
 }
 
+func printStarredPackage(n int, pkg registry.Package) {
+	// Display package
+	fmt.Printf("%d. 📦 %s", n, pkg.FullName())
+	
+	// Show pull count if available
+	if pkg.PullCount > 0 {
+		fmt.Printf(" 📥 %d", pkg.PullCount)
+	}
+	
+	fmt.Println()
+	
+	// Show description
+	if pkg.Description != "" {
+		fmt.Printf("   %s\n", pkg.Description)
+	}
+	
+	// Show how to unstar
+	if pkg.Org != "" && pkg.Name != "" {
+		fmt.Printf("   Unstar: promptbucket unstar %s\n", pkg.FullName())
+	}
+	
+	fmt.Println()
+}
+
 func init() {
 	rootCmd.AddCommand(starCmd)
 	rootCmd.AddCommand(unstarCmd)
 	rootCmd.AddCommand(starsCmd)
+	starsCmd.Flags().Int("limit", 20, "Number of packages per page")
+	addPagingFlags(starsCmd)
 }
\ No newline at end of file
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/promptbucket/cli/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "Manage a package's dist-tags",
+	Long: `Manage dist-tags: names such as latest, beta or stable that point at a
+published version and can be moved as new versions are released.
+
+Consumers use them as org/name:@tag with pull, fetch and install, and in a
+manifest's from: field. When set, latest is what 'pull org/name' resolves to.
+Tag a version as you push it with 'promptbucket push --tag beta'.`,
+}
+
+var tagAddCmd = &cobra.Command{
+	Use:   "add org/name:version tag",
+	Short: "Point a dist-tag at a version, moving it if it exists",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ref, err := parsePackageRef(args[0])
+		if err != nil {
+			return err
+		}
+		if ref.Version == "" {
+			return fmt.Errorf("tag add needs an exact version, e.g. %s/%s:1.2.0", ref.Org, ref.Name)
+		}
+		tag := args[1]
+		if err := registry.CheckDistTag(tag); err != nil {
+			return err
+		}
+
+		client, err := ref.client()
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+
+		pkg, err := client.Package(cmd.Context(), ref.Org, ref.Name)
+		if registry.IsNotFound(err) {
+			return fmt.Errorf("package not found: %s/%s", ref.Org, ref.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get package info: %w", err)
+		}
+		var target *registry.Version
+		for i := range pkg.Versions {
+			if pkg.Versions[i].Tag == ref.Version {
+				target = &pkg.Versions[i]
+			}
+		}
+		if target == nil {
+			return fmt.Errorf("version not found: %s", ref)
+		}
+		previous, moving := pkg.DistTags[tag]
+		if previous == ref.Version {
+			fmt.Printf("✅ %s already points at %s\n", tag, ref.Version)
+			return nil
+		}
+
+		if err := client.SetDistTag(cmd.Context(), ref.Org, ref.Name, tag, ref.Version); err != nil {
+			return fmt.Errorf("failed to set dist-tag %s: %w", tag, err)
+		}
+		if moving {
+			fmt.Printf("✅ Moved %s of %s/%s from %s to %s\n", tag, ref.Org, ref.Name, previous, ref.Version)
+		} else {
+			fmt.Printf("✅ Tagged %s as %s\n", ref, tag)
+		}
+		warnVersionStatus(ref.Org+"/"+ref.Name, *target)
+		fmt.Printf("   Use it with: promptbucket pull %s/%s:@%s\n", ref.Org, ref.Name, tag)
+		return nil
+	},
+}
+
+var tagRmCmd = &cobra.Command{
+	Use:   "rm org/name[:version] tag",
+	Short: "Remove a dist-tag",
+	Long: `Remove a dist-tag. The version it pointed at stays published. If a version
+is given, the tag is only removed while it still points at that version.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ref, err := parsePackageRef(args[0])
+		if err != nil {
+			return err
+		}
+		tag := args[1]
+
+		client, err := ref.client()
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+
+		pkg, err := client.Package(cmd.Context(), ref.Org, ref.Name)
+		if registry.IsNotFound(err) {
+			return fmt.Errorf("package not found: %s/%s", ref.Org, ref.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get package info: %w", err)
+		}
+		target, ok := pkg.DistTags[tag]
+		if !ok {
+			return fmt.Errorf("%s/%s has no dist-tag %q", ref.Org, ref.Name, tag)
+		}
+		if ref.Version != "" && ref.Version != target {
+			return fmt.Errorf("%s points at %s, not %s; nothing removed", tag, target, ref.Version)
+		}
+
+		if err := client.RemoveDistTag(cmd.Context(), ref.Org, ref.Name, tag); err != nil {
+			return fmt.Errorf("failed to remove dist-tag %s: %w", tag, err)
+		}
+		fmt.Printf("✅ Removed %s from %s/%s (was %s)\n", tag, ref.Org, ref.Name, target)
+		return nil
+	},
+}
+
+var tagLsCmd = &cobra.Command{
+	Use:   "ls org/name",
+	Short: "List a package's dist-tags and the versions they point at",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ref, err := parsePackageRef(args[0])
+		if err != nil {
+			return err
+		}
+
+		client, err := ref.client()
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+
+		pkg, err := client.Package(cmd.Context(), ref.Org, ref.Name)
+		if registry.IsNotFound(err) {
+			return fmt.Errorf("package not found: %s/%s", ref.Org, ref.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get package info: %w", err)
+		}
+		if len(pkg.DistTags) == 0 {
+			fmt.Printf("%s has no dist-tags. Add one with 'promptbucket tag add %s:version tag'.\n", pkg.FullName(), pkg.FullName())
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TAG\tVERSION")
+		for _, tag := range sortedDistTags(pkg.DistTags) {
+			fmt.Fprintf(w, "%s\t%s\n", tag, pkg.DistTags[tag])
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+// sortedDistTags returns dist-tag names with latest first, then in order.
+func sortedDistTags(tags map[string]string) []string {
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == registry.LatestTag) != (names[j] == registry.LatestTag) {
+			return names[i] == registry.LatestTag
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+func init() {
+	tagCmd.AddCommand(tagAddCmd, tagRmCmd, tagLsCmd)
+	rootCmd.AddCommand(tagCmd)
+}
@@ -35,7 +35,7 @@ var testAuthCmd = &cobra.Command{
 		fmt.Printf("   API: %s\n", config.GetAPIURL(""))
 
 		// Make health check request
-		resp, err := apiClient.Get("/healthz")
+		resp, err := apiClient.Get(cmd.Context(), "/healthz")
 		if err != nil {
 			return fmt.Errorf("API request failed: %w", err)
 		}
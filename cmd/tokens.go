@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/promptbucket/cli/internal/packager"
+	"github.com/promptbucket/cli/internal/tokens"
+	"github.com/spf13/cobra"
+)
+
+var tokensCmd = &cobra.Command{
+	Use:   "tokens [prompt_name]",
+	Short: "Count the tokens in a rendered prompt",
+	Long: `Count the tokens the rendered persona and prompt will use, without any
+network access.
+
+The tokenizer is chosen from the manifest's model_hint. OpenAI encodings are
+counted exactly; other families are estimated from the closest public
+tokenizer. Variables are filled from --var, falling back to each variable's
+example value.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		varFlags, _ := cmd.Flags().GetStringArray("var")
+		familyName, _ := cmd.Flags().GetString("family")
+		all, _ := cmd.Flags().GetBool("all")
+
+		manifestPath := packager.ManifestFile
+		if len(args) > 0 {
+			manifestPath = resolveManifestArg(args[0])
+		}
+
+		// Load and flatten manifest
+		m, err := packager.LoadManifestFromPath(cmd.Context(), manifestPath)
+		if err != nil {
+			return err
+		}
+		flattened, err := packager.FlattenManifest(cmd.Context(), m)
+		if err != nil {
+			return err
+		}
+
+		// Fill variables, using examples for any not given
+		vars := packager.ExampleVariables(flattened)
+		given, err := packager.ParseVarFlags(varFlags)
+		if err != nil {
+			return err
+		}
+		for k, v := range given {
+			vars[k] = v
+		}
+
+		// Pick tokenizer families
+		var families []tokens.Family
+		switch {
+		case all:
+			families = tokens.Families()
+		case familyName != "":
+			f, ok := tokens.Lookup(familyName)
+			if !ok {
+				return fmt.Errorf("unknown tokenizer family %q (available: %s)", familyName, strings.Join(tokens.Names(), ", "))
+			}
+			families = []tokens.Family{f}
+		default:
+			f, matched := tokens.ForModel(flattened.ModelHint)
+			if !matched && flattened.ModelHint != "" {
+				fmt.Printf("⚠️  Unrecognized model_hint %q, counting with %s\n", flattened.ModelHint, f.Name)
+			}
+			families = []tokens.Family{f}
+		}
+
+		rendered := packager.RenderPrompt(flattened, vars)
+		prompt := packager.SubstituteVariables(flattened.Prompt, vars)
+		persona := strings.TrimSuffix(rendered, prompt)
+
+		fmt.Printf("🔢 Token counts for %s@%s\n\n", flattened.Name, flattened.Version)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "FAMILY\tPERSONA\tPROMPT\tTOTAL\tBUDGET")
+		over := false
+		for _, f := range families {
+			personaTokens, err := f.Count(persona)
+			if err != nil {
+				return err
+			}
+			promptTokens, err := f.Count(prompt)
+			if err != nil {
+				return err
+			}
+			total, err := f.Count(rendered)
+			if err != nil {
+				return err
+			}
+
+			name := f.Name
+			if !f.Exact {
+				name += " (estimate)"
+			}
+			budget := "-"
+			if flattened.MaxTokensBudget > 0 {
+				budget = fmt.Sprintf("%d%% of %d", total*100/flattened.MaxTokensBudget, flattened.MaxTokensBudget)
+				if total > flattened.MaxTokensBudget {
+					budget += " ❌"
+					over = true
+				}
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\n", name, personaTokens, promptTokens, total, budget)
+		}
+		w.Flush()
+
+		if over {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("rendered prompt exceeds max_tokens_budget of %d", flattened.MaxTokensBudget)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tokensCmd)
+	tokensCmd.Flags().StringArray("var", []string{}, "Variable substitution (key=value, repeatable)")
+	tokensCmd.Flags().String("family", "", "Tokenizer family to count with ("+strings.Join(tokens.Names(), "|")+")")
+	tokensCmd.Flags().Bool("all", false, "Count with every tokenizer family")
+}
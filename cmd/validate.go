@@ -1,342 +1,626 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
 
+	"github.com/promptbucket/cli/internal/diag"
 	"github.com/promptbucket/cli/internal/packager"
+	"github.com/promptbucket/cli/internal/registry"
+	"github.com/promptbucket/cli/internal/schema"
+	"github.com/promptbucket/cli/internal/semver"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
 
 var validateCmd = &cobra.Command{
-	Use:   "validate [prompt_name]",
-	Short: "Validate a prompt manifest file",
-	Long: `Validate a prompt manifest file against the schema.
+	Use:   "validate [target...]",
+	Short: "Validate prompt manifest files",
+	Long: `Validate prompt manifest files against the schema.
 
-If no prompt_name is specified, validates the current directory's promptbucket.yaml.
-If prompt_name is specified, looks for promptbucket.yaml in that directory or treats it as a file path.`,
-	Args: cobra.MaximumNArgs(1),
+If no target is specified, validates the current directory's promptbucket.yaml.
+Each target may be:
+  - a manifest file or a directory containing promptbucket.yaml
+  - a directory followed by /... to find every manifest below it (e.g. ./...)
+  - a glob pattern (e.g. 'prompts/*/promptbucket.yaml')
+  - a .promptbucket archive
+  - a registry package reference: org/name:1.0.0, org/name:@tag or
+    org/name@range, optionally prefixed with registry:
+
+Targets are checked in parallel. With more than one target a summary table is
+printed, and the command fails if any target fails.
+
+Every problem is reported with its file, line, column and rule ID. Use --format
+to produce JSON, GitHub Actions annotations or SARIF for editors and CI.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		var manifestPath string
-		
-		if len(args) == 0 {
-			// No argument provided, use current directory
-			manifestPath = packager.ManifestFile
+		format, _ := cmd.Flags().GetString("format")
+		jobs, _ := cmd.Flags().GetInt("jobs")
+
+		targets, err := collectValidateTargets(args)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+
+		// Keep the detailed single-manifest output when there is only one target
+		single := len(targets) == 1
+		results := runValidateTargets(cmd.Context(), targets, jobs, single && format == "text")
+
+		var all []diag.Diagnostic
+		failed := 0
+		for _, r := range results {
+			all = append(all, r.diags...)
+			if r.err != nil {
+				all = append(all, diag.Diagnostic{
+					File:     r.target.name,
+					Rule:     "validate/unreadable",
+					Severity: diag.SeverityError,
+					Message:  r.err.Error(),
+				})
+			}
+			if !r.passed() {
+				failed++
+			}
+		}
+
+		if format != "text" {
+			if err := diag.Write(os.Stdout, format, "promptbucket-validate", nil, all); err != nil {
+				return err
+			}
+		} else if single {
+			r := results[0]
+			if r.err != nil {
+				return r.err
+			}
+			if !r.passed() {
+				fmt.Printf("❌ Validation failed for %s:\n", r.target.name)
+			}
+			if err := diag.Write(os.Stdout, "text", "", nil, r.diags); err != nil {
+				return err
+			}
+			if r.passed() {
+				fmt.Printf("✅ %s is valid\n", r.target.name)
+			}
 		} else {
-			promptName := args[0]
-			
-			// Check if it's a file path
-			if filepath.Ext(promptName) == ".yaml" || filepath.Ext(promptName) == ".yml" {
-				manifestPath = promptName
-			} else {
-				// Assume it's a directory name
-				manifestPath = filepath.Join(promptName, packager.ManifestFile)
+			printValidateSummary(results)
+		}
+
+		if failed > 0 {
+			if single {
+				if results[0].err != nil {
+					return results[0].err
+				}
+				return fmt.Errorf("validation failed with %d error(s)", diag.Count(all, diag.SeverityError))
 			}
+			return fmt.Errorf("%d of %d target(s) failed validation", failed, len(results))
 		}
-		
-		return validateManifest(manifestPath)
+		return nil
 	},
 }
 
-func validateManifest(path string) error {
-	// Check if file exists
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return fmt.Errorf("manifest file not found: %s", path)
-	}
-	
-	// Read the manifest file
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return fmt.Errorf("failed to read manifest file %s: %w", path, err)
+type validateTargetKind int
+
+const (
+	targetManifest validateTargetKind = iota
+	targetArchive
+	targetRemote
+)
+
+// validateTarget is one thing to validate, as named on the command line.
+type validateTarget struct {
+	kind validateTargetKind
+	// name is the path or reference reported in diagnostics.
+	name string
+	// path is the local file for manifests and archives.
+	path string
+	ref  packageRef
+}
+
+type validateResult struct {
+	target validateTarget
+	diags  []diag.Diagnostic
+	// err is set when the target could not be checked at all.
+	err error
+}
+
+func (r validateResult) passed() bool {
+	return r.err == nil && diag.Count(r.diags, diag.SeverityError) == 0
+}
+
+// collectValidateTargets expands command arguments into validation targets,
+// dropping duplicates while keeping argument order.
+func collectValidateTargets(args []string) ([]validateTarget, error) {
+	if len(args) == 0 {
+		args = []string{packager.ManifestFile}
 	}
-	
-	// Parse YAML
-	var manifest packager.Manifest
-	if err := yaml.Unmarshal(data, &manifest); err != nil {
-		return fmt.Errorf("invalid YAML syntax in %s: %w", path, err)
-	}
-	
-	// Validate required fields
-	var errors []string
-	
-	if manifest.Name == "" {
-		errors = append(errors, "missing required field: name")
-	}
-	
-	if manifest.Version == "" {
-		errors = append(errors, "missing required field: version")
-	}
-	
-	if manifest.Licence == "" {
-		errors = append(errors, "missing required field: licence")
-	}
-	
-	if manifest.Prompt == "" {
-		errors = append(errors, "missing required field: prompt")
-	}
-	
-	// Validate name pattern
-	if manifest.Name != "" {
-		if !validateNamePattern(manifest.Name) {
-			errors = append(errors, "name must match pattern: ^[a-z0-9]([a-z0-9-_]{0,38}[a-z0-9])?$")
+
+	var targets []validateTarget
+	seen := make(map[string]bool)
+	add := func(t validateTarget) {
+		if !seen[t.name] {
+			seen[t.name] = true
+			targets = append(targets, t)
 		}
 	}
-	
-	// Validate version pattern (semantic versioning)
-	if manifest.Version != "" {
-		if !validateVersionPattern(manifest.Version) {
-			errors = append(errors, "version must follow semantic versioning: major.minor.patch")
+
+	for _, arg := range args {
+		switch {
+		case arg == "..." || strings.HasSuffix(arg, "/..."):
+			root := strings.TrimSuffix(strings.TrimSuffix(arg, "..."), "/")
+			if root == "" {
+				root = "."
+			}
+			paths, err := discoverManifests(root)
+			if err != nil {
+				return nil, err
+			}
+			if len(paths) == 0 {
+				return nil, fmt.Errorf("no %s found under %s", packager.ManifestFile, root)
+			}
+			for _, p := range paths {
+				add(pathTarget(p))
+			}
+
+		case strings.ContainsAny(arg, "*?["):
+			matches, err := filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %s: %w", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %s", arg)
+			}
+			for _, m := range matches {
+				add(pathTarget(m))
+			}
+
+		default:
+			if _, err := os.Stat(arg); err != nil {
+				// A bare org/name is more likely a mistyped directory, so
+				// only references naming a version, tag, range or registry
+				// go to the registry
+				ref, err := parsePackageRef(arg)
+				if ref.Version != "" || ref.DistTag != "" || ref.Constraint != "" || ref.Registry != "" {
+					if err != nil {
+						return nil, err
+					}
+					add(validateTarget{kind: targetRemote, name: ref.String(), ref: ref})
+					continue
+				}
+			}
+			add(pathTarget(arg))
 		}
 	}
-	
-	// Validate variables
-	if len(manifest.Variables) > 0 {
-		if err := validateVariables(manifest.Variables); err != nil {
-			errors = append(errors, err.Error())
-		}
+	return targets, nil
+}
+
+// pathTarget classifies a local path as an archive or a manifest.
+func pathTarget(path string) validateTarget {
+	if filepath.Ext(path) == ".promptbucket" {
+		return validateTarget{kind: targetArchive, name: path + "!/" + packager.ManifestFile, path: path}
 	}
-	
-	// Validate persona
-	if manifest.Persona != nil {
-		if err := validatePersona(manifest.Persona); err != nil {
-			errors = append(errors, err.Error())
+	manifest := resolveManifestArg(path)
+	return validateTarget{kind: targetManifest, name: manifest, path: manifest}
+}
+
+// discoverManifests finds every manifest below root, skipping hidden and
+// dependency directories.
+func discoverManifests(root string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
 		}
-	}
-	
-	// Report validation results
-	if len(errors) > 0 {
-		fmt.Printf("❌ Validation failed for %s:\n", path)
-		for _, err := range errors {
-			fmt.Printf("  - %s\n", err)
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
 		}
-		return fmt.Errorf("validation failed")
+		if d.Name() == packager.ManifestFile {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search %s: %w", root, err)
 	}
-	
-	fmt.Printf("✅ %s is valid\n", path)
-	
-	// Try to flatten manifest if it has inheritance
-	if manifest.From != "" {
-		fmt.Printf("ℹ️  Checking inheritance chain...\n")
-		_, err := packager.FlattenManifest(&manifest)
+	return paths, nil
+}
+
+// runValidateTargets checks targets concurrently with at most jobs workers
+// and returns results in target order.
+func runValidateTargets(ctx context.Context, targets []validateTarget, jobs int, verbose bool) []validateResult {
+	if jobs < 1 {
+		jobs = runtime.NumCPU()
+	}
+
+	results := make([]validateResult, len(targets))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs && w < len(targets); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				t := targets[i]
+				diags, err := checkTarget(ctx, t, verbose)
+				results[i] = validateResult{target: t, diags: diags, err: err}
+			}
+		}()
+	}
+	for i := range targets {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// checkTarget loads a target's manifest and validates it.
+func checkTarget(ctx context.Context, t validateTarget, verbose bool) ([]diag.Diagnostic, error) {
+	switch t.kind {
+	case targetArchive:
+		data, err := packager.ReadArchiveManifest(t.path)
 		if err != nil {
-			fmt.Printf("⚠️  Warning: inheritance validation failed: %s\n", err)
-		} else {
-			fmt.Printf("✅ Inheritance chain is valid\n")
+			return nil, err
+		}
+		return checkManifestSource(ctx, t.name, filepath.Join(filepath.Dir(t.path), packager.ManifestFile), data, verbose)
+
+	case targetRemote:
+		client, err := t.ref.client()
+		if err != nil {
+			return nil, err
 		}
+		data, err := fetchManifest(ctx, client, t.ref)
+		if err != nil {
+			return nil, err
+		}
+		return checkManifestSource(ctx, t.name, packager.ManifestFile, data, verbose)
+
+	default:
+		return checkManifest(ctx, t.path, verbose)
 	}
-	
-	return nil
 }
 
-func validateNamePattern(name string) bool {
-	// Pattern: ^[a-z0-9]([a-z0-9-_]{0,38}[a-z0-9])?$
-	if len(name) == 0 || len(name) > 40 {
-		return false
-	}
-	
-	// First character must be alphanumeric
-	first := name[0]
-	if !((first >= 'a' && first <= 'z') || (first >= '0' && first <= '9')) {
-		return false
-	}
-	
-	// Single character names are valid
-	if len(name) == 1 {
-		return true
-	}
-	
-	// Last character must be alphanumeric
-	last := name[len(name)-1]
-	if !((last >= 'a' && last <= 'z') || (last >= '0' && last <= '9')) {
-		return false
-	}
-	
-	// Middle characters can be alphanumeric, dash, or underscore
-	for i := 1; i < len(name)-1; i++ {
-		c := name[i]
-		if !((c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_') {
-			return false
+// fetchManifest downloads a published manifest from the registry. A
+// reference without an exact version is resolved against the published
+// versions first, as pull does.
+func fetchManifest(ctx context.Context, client *registry.Client, ref packageRef) ([]byte, error) {
+	version := ref.Version
+	if version == "" {
+		pkg, err := client.Package(ctx, ref.Org, ref.Name)
+		if registry.IsNotFound(err) {
+			return nil, fmt.Errorf("package not found: %s/%s", ref.Org, ref.Name)
 		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get package info: %w", err)
+		}
+		resolved, err := registry.Resolve(pkg, ref.resolvable())
+		if err != nil {
+			return nil, err
+		}
+		version = resolved.Tag
+	}
+
+	data, err := client.Manifest(ctx, ref.Org, ref.Name, version)
+	if registry.IsNotFound(err) {
+		return nil, fmt.Errorf("package not found: %s/%s:%s", ref.Org, ref.Name, version)
 	}
-	
-	return true
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s/%s:%s: %w", ref.Org, ref.Name, version, err)
+	}
+	return data, nil
 }
 
-func validateVersionPattern(version string) bool {
-	// Basic semantic versioning check: major.minor.patch with optional pre-release
-	parts := make([]rune, 0)
-	dotCount := 0
-	
-	for _, r := range version {
-		if r == '.' {
-			dotCount++
-			if dotCount > 2 {
-				return false
-			}
-		} else if r >= '0' && r <= '9' {
-			// digits are ok
-		} else if r == '-' && dotCount == 2 {
-			// pre-release suffix after patch version is ok
-			break
+// printValidateSummary prints every target's diagnostics followed by a
+// one-line-per-target table.
+func printValidateSummary(results []validateResult) {
+	for _, r := range results {
+		if r.err != nil {
+			fmt.Printf("%s: error: %s\n", r.target.name, r.err)
+			continue
+		}
+		diag.Write(os.Stdout, "text", "", nil, r.diags)
+	}
+	fmt.Println()
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tTARGET\tERRORS\tWARNINGS")
+	passed := 0
+	for _, r := range results {
+		status := "✅ ok"
+		errors := diag.Count(r.diags, diag.SeverityError)
+		if r.err != nil {
+			status = "❌ error"
+			errors++
+		} else if errors > 0 {
+			status = "❌ fail"
 		} else {
-			return false
+			passed++
 		}
-		parts = append(parts, r)
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\n", status, r.target.name, errors, diag.Count(r.diags, diag.SeverityWarning))
 	}
-	
-	return dotCount == 2
+	tw.Flush()
+
+	fmt.Printf("\n%d passed, %d failed, %d total\n", passed, len(results)-passed, len(results))
 }
 
-func validateVariables(variables []packager.Variable) error {
-	names := make(map[string]bool)
-	
-	for _, v := range variables {
-		// Check for duplicate names
-		if names[v.Name] {
-			return fmt.Errorf("duplicate variable name: %s", v.Name)
+// resolveManifestArg maps a command argument to a manifest path: YAML files
+// are used as-is, anything else is treated as a directory holding a manifest.
+func resolveManifestArg(arg string) string {
+	if filepath.Ext(arg) == ".yaml" || filepath.Ext(arg) == ".yml" {
+		return arg
+	}
+	return filepath.Join(arg, packager.ManifestFile)
+}
+
+// checkManifest runs every validation on the manifest at path and returns
+// all problems found. An error is returned only if the file cannot be read.
+// With verbose set, progress of the inheritance check is printed.
+func checkManifest(ctx context.Context, path string, verbose bool) ([]diag.Diagnostic, error) {
+	// Check if file exists
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("manifest file not found: %s", path)
+	}
+
+	// Read the manifest file
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest file %s: %w", path, err)
+	}
+
+	return checkManifestSource(ctx, path, path, data, verbose)
+}
+
+// checkManifestSource validates manifest data reported under name.
+// allowlistFor is the manifest path whose secrets allowlist applies.
+func checkManifestSource(ctx context.Context, name, allowlistFor string, data []byte, verbose bool) ([]diag.Diagnostic, error) {
+	path := name
+
+	// Parse YAML, keeping node positions for diagnostics
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return []diag.Diagnostic{yamlSyntaxDiagnostic(path, err)}, nil
+	}
+	var manifest packager.Manifest
+	if err := doc.Decode(&manifest); err != nil {
+		// Type mismatches are reported by the schema below
+		manifest = packager.Manifest{}
+	}
+
+	// Validate against the manifest schema
+	violations, err := schema.ValidateNode(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate %s: %w", path, err)
+	}
+
+	var diags []diag.Diagnostic
+	for _, v := range violations {
+		msg := v.Message
+		if v.Path == "/version" && v.Keyword == "pattern" {
+			// The semver pattern is unreadable; explain what is wrong instead
+			if _, err := semver.Parse(manifest.Version); err != nil {
+				msg = err.Error() + " (expected MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD])"
+			}
 		}
-		names[v.Name] = true
-		
-		// Validate variable name pattern
-		if !validateVariableName(v.Name) {
-			return fmt.Errorf("variable name '%s' must match pattern: ^[a-zA-Z][a-zA-Z0-9_]*$", v.Name)
+		if field := schema.FieldPath(v.Path); field != "" {
+			msg = field + ": " + msg
+		}
+		diags = append(diags, diag.Diagnostic{
+			File:     path,
+			Line:     v.Line,
+			Column:   v.Column,
+			Rule:     "schema/" + v.Keyword,
+			Severity: diag.SeverityError,
+			Message:  msg,
+		})
+	}
+
+	// Checks the schema cannot express
+	diags = append(diags, checkVariableNames(path, &doc, manifest.Variables)...)
+	diags = append(diags, checkCompatibility(path, &doc, &manifest)...)
+	diags = append(diags, checkDependencyRanges(path, &doc, manifest.Dependencies)...)
+
+	// Scan for credentials and personal data
+	findings, err := scanSource(allowlistFor, path, data)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range findings {
+		diags = append(diags, f.Diagnostic())
+	}
+
+	// Try to flatten manifest if it has inheritance
+	if manifest.From != "" && diag.Count(diags, diag.SeverityError) == 0 {
+		if verbose {
+			fmt.Printf("ℹ️  Checking inheritance chain...\n")
 		}
-		
-		// Validate description length
-		if len(v.Description) > 120 {
-			return fmt.Errorf("variable '%s' description exceeds 120 characters", v.Name)
+		if _, err := packager.FlattenManifest(ctx, &manifest); err != nil {
+			key, _ := schema.Locate(&doc, "/from")
+			d := diag.Diagnostic{
+				File:     path,
+				Rule:     "inheritance/unresolved",
+				Severity: diag.SeverityWarning,
+				Message:  fmt.Sprintf("inheritance validation failed: %s", err),
+			}
+			if key != nil {
+				d.Line, d.Column = key.Line, key.Column
+			}
+			diags = append(diags, d)
+		} else if verbose {
+			fmt.Printf("✅ Inheritance chain is valid\n")
 		}
-		
-		// Validate example length
-		if len(v.Example) > 120 {
-			return fmt.Errorf("variable '%s' example exceeds 120 characters", v.Name)
+	}
+
+	// Check the rendered prompt against its token budget
+	if manifest.MaxTokensBudget > 0 && diag.Count(diags, diag.SeverityError) == 0 {
+		if d, ok := checkTokenBudget(ctx, path, &doc, &manifest); ok {
+			diags = append(diags, d)
 		}
 	}
-	
-	return nil
+
+	diag.Sort(diags)
+	return diags, nil
 }
 
-func validateVariableName(name string) bool {
-	if len(name) == 0 {
-		return false
-	}
-	
-	// First character must be a letter
-	first := name[0]
-	if !((first >= 'a' && first <= 'z') || (first >= 'A' && first <= 'Z')) {
-		return false
-	}
-	
-	// Remaining characters must be letters, digits, or underscores
-	for i := 1; i < len(name); i++ {
-		c := name[i]
-		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_') {
-			return false
+// checkTokenBudget renders the prompt with each variable's example value and
+// reports it if it exceeds max_tokens_budget. Manifests whose parents cannot
+// be resolved are skipped; that is reported separately.
+func checkTokenBudget(ctx context.Context, path string, doc *yaml.Node, manifest *packager.Manifest) (diag.Diagnostic, bool) {
+	flattened := manifest
+	if manifest.From != "" {
+		var err error
+		if flattened, err = packager.FlattenManifest(ctx, manifest); err != nil {
+			return diag.Diagnostic{}, false
 		}
 	}
-	
-	return true
+
+	d := diag.Diagnostic{
+		File:     path,
+		Rule:     "tokens/budget-exceeded",
+		Severity: diag.SeverityError,
+	}
+	if key, _ := schema.Locate(doc, "/max_tokens_budget"); key != nil {
+		d.Line, d.Column = key.Line, key.Column
+	}
+	if err := packager.CheckTokenBudget(flattened, packager.RenderPrompt(flattened, packager.ExampleVariables(flattened))); err != nil {
+		d.Message = err.Error()
+		return d, true
+	}
+	return d, false
 }
 
-func validatePersona(persona *packager.Persona) error {
-	// Validate field lengths according to schema
-	if len(persona.Name) > 100 {
-		return fmt.Errorf("persona name exceeds 100 characters")
-	}
-	
-	if len(persona.Role) > 100 {
-		return fmt.Errorf("persona role exceeds 100 characters")
-	}
-	
-	if len(persona.Experience) > 100 {
-		return fmt.Errorf("persona experience exceeds 100 characters")
-	}
-	
-	if len(persona.Background) > 500 {
-		return fmt.Errorf("persona background exceeds 500 characters")
-	}
-	
-	if len(persona.Tone) > 50 {
-		return fmt.Errorf("persona tone exceeds 50 characters")
-	}
-	
-	if len(persona.Style) > 50 {
-		return fmt.Errorf("persona style exceeds 50 characters")
-	}
-	
-	if len(persona.LanguageLevel) > 50 {
-		return fmt.Errorf("persona language_level exceeds 50 characters")
-	}
-	
-	if len(persona.Approach) > 50 {
-		return fmt.Errorf("persona approach exceeds 50 characters")
-	}
-	
-	if len(persona.InteractionStyle) > 200 {
-		return fmt.Errorf("persona interaction_style exceeds 200 characters")
-	}
-	
-	if len(persona.OutputFormat) > 50 {
-		return fmt.Errorf("persona output_format exceeds 50 characters")
-	}
-	
-	// Validate array limits
-	if len(persona.Personality) > 10 {
-		return fmt.Errorf("persona personality array exceeds 10 items")
-	}
-	
-	if len(persona.Expertise) > 20 {
-		return fmt.Errorf("persona expertise array exceeds 20 items")
-	}
-	
-	if len(persona.Focus) > 10 {
-		return fmt.Errorf("persona focus array exceeds 10 items")
-	}
-	
-	if len(persona.Constraints) > 10 {
-		return fmt.Errorf("persona constraints array exceeds 10 items")
-	}
-	
-	if len(persona.Preferences) > 10 {
-		return fmt.Errorf("persona preferences array exceeds 10 items")
-	}
-	
-	// Validate individual array item lengths
-	for _, p := range persona.Personality {
-		if len(p) > 50 {
-			return fmt.Errorf("persona personality item '%s' exceeds 50 characters", p)
+// checkCompatibility reports manifests written for a newer CLI, and warns
+// about unversioned manifests that should be migrated.
+func checkCompatibility(path string, doc *yaml.Node, manifest *packager.Manifest) []diag.Diagnostic {
+	var diags []diag.Diagnostic
+	at := func(d diag.Diagnostic, pointer string) diag.Diagnostic {
+		if key, _ := schema.Locate(doc, pointer); key != nil {
+			d.Line, d.Column = key.Line, key.Column
+		} else {
+			d.Line, d.Column = 1, 1
 		}
+		return d
 	}
-	
-	for _, e := range persona.Expertise {
-		if len(e) > 50 {
-			return fmt.Errorf("persona expertise item '%s' exceeds 50 characters", e)
-		}
+
+	if manifest.SchemaVersion == 0 {
+		diags = append(diags, at(diag.Diagnostic{
+			File:     path,
+			Rule:     "compat/schema-version",
+			Severity: diag.SeverityWarning,
+			Message:  fmt.Sprintf("manifest has no schema_version; run 'promptbucket migrate' to upgrade it to version %d", packager.SchemaVersion),
+		}, "/schema_version"))
+	} else if err := packager.CheckSchemaVersion(manifest); err != nil {
+		diags = append(diags, at(diag.Diagnostic{
+			File:     path,
+			Rule:     "compat/schema-version",
+			Severity: diag.SeverityError,
+			Message:  err.Error(),
+		}, "/schema_version"))
 	}
-	
-	for _, f := range persona.Focus {
-		if len(f) > 50 {
-			return fmt.Errorf("persona focus item '%s' exceeds 50 characters", f)
+
+	// Malformed requirements are reported by the schema
+	if manifest.Engines == nil {
+		return diags
+	}
+	if _, err := packager.ParseEngineRequirement(manifest.Engines.Promptbucket); err != nil {
+		return diags
+	}
+	if err := packager.CheckEngines(manifest); err != nil {
+		diags = append(diags, at(diag.Diagnostic{
+			File:     path,
+			Rule:     "compat/engine",
+			Severity: diag.SeverityError,
+			Message:  err.Error(),
+		}, "/engines/promptbucket"))
+	}
+	return diags
+}
+
+// checkDependencyRanges reports dependency ranges that are not valid semver
+// ranges, which 'promptbucket install' would refuse.
+func checkDependencyRanges(path string, doc *yaml.Node, deps map[string]string) []diag.Diagnostic {
+	var diags []diag.Diagnostic
+	for name, r := range deps {
+		if _, err := semver.ParseRange(r); err == nil || r == "" {
+			continue
 		}
-	}
-	
-	for _, c := range persona.Constraints {
-		if len(c) > 200 {
-			return fmt.Errorf("persona constraint '%s' exceeds 200 characters", c)
+		if strings.HasPrefix(r, "@") && registry.CheckDistTag(r[1:]) == nil {
+			continue
+		}
+		d := diag.Diagnostic{
+			File:     path,
+			Rule:     "dependencies/invalid-range",
+			Severity: diag.SeverityError,
+			Message:  fmt.Sprintf("dependency %s: %q is not a semver range or dist-tag (e.g. ^1.2, ~1.2.3, >=1.0 <2, @stable)", name, r),
+		}
+		pointer := "/dependencies/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+		if _, n := schema.Locate(doc, pointer); n != nil {
+			d.Line, d.Column = n.Line, n.Column
 		}
+		diags = append(diags, d)
 	}
-	
-	for _, p := range persona.Preferences {
-		if len(p) > 200 {
-			return fmt.Errorf("persona preference '%s' exceeds 200 characters", p)
+	return diags
+}
+
+// checkVariableNames reports every variable whose name repeats an earlier
+// one. uniqueItems in the schema only rejects identical entries, not two
+// entries sharing a name.
+func checkVariableNames(path string, doc *yaml.Node, variables []packager.Variable) []diag.Diagnostic {
+	var diags []diag.Diagnostic
+	names := make(map[string]bool)
+
+	for i, v := range variables {
+		if !names[v.Name] {
+			names[v.Name] = true
+			continue
+		}
+		d := diag.Diagnostic{
+			File:     path,
+			Rule:     "variables/duplicate-name",
+			Severity: diag.SeverityError,
+			Message:  fmt.Sprintf("duplicate variable name: %s", v.Name),
 		}
+		if _, n := schema.Locate(doc, fmt.Sprintf("/variables/%d/name", i)); n != nil {
+			d.Line, d.Column = n.Line, n.Column
+		}
+		diags = append(diags, d)
 	}
-	
-	return nil
+
+	return diags
+}
+
+var yamlLineRe = regexp.MustCompile(`line (\d+)`)
+
+// yamlSyntaxDiagnostic converts a YAML parse error, which carries its line
+// only in the message text.
+func yamlSyntaxDiagnostic(path string, err error) diag.Diagnostic {
+	d := diag.Diagnostic{
+		File:     path,
+		Rule:     "yaml/syntax",
+		Severity: diag.SeverityError,
+		Message:  strings.TrimPrefix(err.Error(), "yaml: "),
+	}
+	if m := yamlLineRe.FindStringSubmatch(err.Error()); m != nil {
+		d.Line, _ = strconv.Atoi(m[1])
+		d.Column = 1
+	}
+	return d
 }
 
 func init() {
 	rootCmd.AddCommand(validateCmd)
-}
\ No newline at end of file
+	validateCmd.Flags().StringP("format", "f", "text", "Output format ("+strings.Join(diag.Formats, "|")+")")
+	validateCmd.Flags().IntP("jobs", "j", 0, "Number of targets to check in parallel (default: number of CPUs)")
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/promptbucket/cli/internal/packager"
+	"github.com/promptbucket/cli/internal/registry/registrytest"
+)
+
+func TestFetchManifest(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	for _, v := range []string{"1.0.0", "1.4.0", "2.0.0-beta.1"} {
+		server.AddManifest("acme", "demo", v, []byte("name: demo\nversion: "+v+"\n"))
+	}
+	server.SetDistTag("acme", "demo", "beta", "2.0.0-beta.1")
+	client := server.Client(t.TempDir())
+
+	tests := []struct {
+		spec string
+		want string
+		err  string
+	}{
+		{spec: "acme/demo:1.0.0", want: "1.0.0"},
+		{spec: "acme/demo:@beta", want: "2.0.0-beta.1"},
+		{spec: "acme/demo@^1", want: "1.4.0"},
+		{spec: "acme/demo", want: "1.4.0"},
+		{spec: "acme/demo:@stable", err: `no dist-tag "stable"`},
+		{spec: "acme/demo@^3", err: "no published version of acme/demo matches ^3"},
+		{spec: "acme/demo:9.9.9", err: "package not found: acme/demo:9.9.9"},
+		{spec: "acme/missing:@beta", err: "package not found: acme/missing"},
+	}
+
+	for _, tt := range tests {
+		ref, err := parsePackageRef(tt.spec)
+		if err != nil {
+			t.Fatalf("parsePackageRef(%q): %v", tt.spec, err)
+		}
+		data, err := fetchManifest(context.Background(), client, ref)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("fetchManifest(%s) error = %v, want one containing %q", tt.spec, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("fetchManifest(%s): %v", tt.spec, err)
+			continue
+		}
+		if want := "version: " + tt.want + "\n"; !strings.Contains(string(data), want) {
+			t.Errorf("fetchManifest(%s) = %q, want version %s", tt.spec, data, tt.want)
+		}
+	}
+}
+
+func TestCollectValidateTargets(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	// A directory that happens to look like a reference is still a path
+	if err := os.MkdirAll("acme/local@1", 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		arg  string
+		kind validateTargetKind
+		name string
+		err  string
+	}{
+		{arg: "acme/demo:1.0.0", kind: targetRemote, name: "acme/demo:1.0.0"},
+		{arg: "acme/demo:@beta", kind: targetRemote, name: "acme/demo:@beta"},
+		{arg: "acme/demo@^1.0.0", kind: targetRemote, name: "acme/demo@^1.0.0"},
+		{arg: "acme/demo@>=1.2 <2", kind: targetRemote, name: "acme/demo@>=1.2 <2"},
+		{arg: "work:acme/demo", kind: targetRemote, name: "work:acme/demo"},
+		{arg: "acme/local@1", kind: targetManifest, name: filepath.Join("acme/local@1", packager.ManifestFile)},
+		{arg: "acme/demo", kind: targetManifest, name: filepath.Join("acme/demo", packager.ManifestFile)},
+		{arg: "prompts/demo.yaml", kind: targetManifest, name: "prompts/demo.yaml"},
+		{arg: "dist/demo.promptbucket", kind: targetArchive, name: "dist/demo.promptbucket!/" + packager.ManifestFile},
+		{arg: "acme/demo:@not a tag", err: "invalid dist-tag"},
+	}
+
+	for _, tt := range tests {
+		targets, err := collectValidateTargets([]string{tt.arg})
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("collectValidateTargets(%q) error = %v, want one containing %q", tt.arg, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("collectValidateTargets(%q): %v", tt.arg, err)
+			continue
+		}
+		if len(targets) != 1 || targets[0].kind != tt.kind || targets[0].name != tt.name {
+			t.Errorf("collectValidateTargets(%q) = %+v, want kind %d named %s", tt.arg, targets, tt.kind, tt.name)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/promptbucket/cli/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+var visibilityCmd = &cobra.Command{
+	Use:   "visibility",
+	Short: "Manage who can see a package",
+	Long: `Manage who can see and pull a package.
+
+A package is private (only you), org (members of its org) or public
+(everyone). Set it when first publishing with 'push --visibility', or change
+it later with 'visibility set'. info and list show each package's visibility.`,
+}
+
+var visibilitySetCmd = &cobra.Command{
+	Use:   "set org/name private|org|public",
+	Short: "Change a package's visibility",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ref, err := parsePackageRef(args[0])
+		if err != nil {
+			return err
+		}
+		if ref.Version != "" || ref.Constraint != "" || ref.DistTag != "" {
+			return fmt.Errorf("visibility applies to every version; use %s/%s", ref.Org, ref.Name)
+		}
+		visibility := strings.ToLower(args[1])
+		if err := checkVisibility(visibility); err != nil {
+			return err
+		}
+
+		client, err := ref.client()
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+
+		pkg, err := client.Package(cmd.Context(), ref.Org, ref.Name)
+		if registry.IsNotFound(err) {
+			return fmt.Errorf("package not found: %s", ref)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get package info: %w", err)
+		}
+		if pkg.Visibility == visibility {
+			fmt.Printf("✅ %s is already %s\n", ref, visibilityLabel(visibility))
+			return nil
+		}
+
+		if err := client.SetVisibility(cmd.Context(), ref.Org, ref.Name, visibility); err != nil {
+			return fmt.Errorf("failed to set visibility: %w", err)
+		}
+		if pkg.Visibility != "" {
+			fmt.Printf("✅ %s is now %s (was %s)\n", ref, visibilityLabel(visibility), pkg.Visibility)
+		} else {
+			fmt.Printf("✅ %s is now %s\n", ref, visibilityLabel(visibility))
+		}
+		if visibility == registry.VisibilityPublic {
+			fmt.Println("   Anyone can now see and pull every version of it.")
+		}
+		return nil
+	},
+}
+
+// checkVisibility returns an error unless v is a known visibility.
+func checkVisibility(v string) error {
+	for _, known := range registry.Visibilities {
+		if v == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid visibility %q (expected %s)", v, strings.Join(registry.Visibilities, ", "))
+}
+
+// visibilityLabel returns a visibility with its icon, or "" if unknown.
+func visibilityLabel(v string) string {
+	switch v {
+	case registry.VisibilityPrivate:
+		return "🔒 private"
+	case registry.VisibilityOrg:
+		return "👥 org"
+	case registry.VisibilityPublic:
+		return "🌐 public"
+	case "":
+		return ""
+	default:
+		return v
+	}
+}
+
+func init() {
+	visibilityCmd.AddCommand(visibilitySetCmd)
+	rootCmd.AddCommand(visibilityCmd)
+}
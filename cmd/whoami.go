@@ -19,7 +19,7 @@ var whoamiCmd = &cobra.Command{
 		// Check if user is logged in
 		if !tokenManager.IsAuthenticated() {
 			fmt.Printf("⚠️  Not logged in\n")
-			fmt.Printf("   Run 'promptbucket login' to authenticate\n")
+			fmt.Printf("   Run '%s' to authenticate\n", config.LoginCommand())
 			return nil
 		}
 
@@ -36,6 +36,9 @@ var whoamiCmd = &cobra.Command{
 
 		// Display user information
 		fmt.Printf("👤 Current User:\n")
+		if config.Registry != auth.DefaultRegistry {
+			fmt.Printf("   Registry: %s (%s)\n", config.Registry, config.BaseURL)
+		}
 		fmt.Printf("   Email: %s\n", token.Email)
 		fmt.Printf("   User ID: %s\n", token.UserID)
 
@@ -2,11 +2,11 @@ package auth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"time"
 )
 
 // APIClient is an HTTP client that automatically handles authentication
@@ -14,23 +14,43 @@ type APIClient struct {
 	config       *Config
 	tokenManager *TokenManager
 	httpClient   *http.Client
+	// err is why the network settings could not be applied; every request
+	// fails with it
+	err error
 }
 
-// NewAPIClient creates a new authenticated API client
+// NewAPIClient creates a new authenticated API client that connects through
+// the transport given to SetTransport, or else one built from the configured
+// proxy and TLS settings
 func NewAPIClient(config *Config) *APIClient {
-	return &APIClient{
+	c := &APIClient{
 		config:       config,
 		tokenManager: NewTokenManager(config),
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   config.Timeout,
+			Transport: transport,
 		},
 	}
+	if transport != nil {
+		return c
+	}
+	network, err := config.Network()
+	if err == nil {
+		c.httpClient.Transport, err = network.Transport()
+	}
+	if err != nil {
+		c.err = fmt.Errorf("invalid network settings: %w", err)
+	}
+	return c
 }
 
-// Request makes an authenticated HTTP request
-func (c *APIClient) Request(method, endpoint string, body interface{}) (*http.Response, error) {
-	url := c.config.GetAPIURL(endpoint)
-	
+// URL returns the absolute URL of an API endpoint
+func (c *APIClient) URL(endpoint string) string {
+	return c.config.GetAPIURL(endpoint)
+}
+
+// Request makes an authenticated HTTP request with a JSON body
+func (c *APIClient) Request(ctx context.Context, method, endpoint string, body interface{}) (*http.Response, error) {
 	// Prepare request body
 	var requestBody io.Reader
 	if body != nil {
@@ -41,61 +61,115 @@ func (c *APIClient) Request(method, endpoint string, body interface{}) (*http.Re
 		requestBody = bytes.NewBuffer(jsonData)
 	}
 
-	// Create request
-	req, err := http.NewRequest(method, url, requestBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	return c.RequestRaw(ctx, method, endpoint, "application/json", requestBody)
+}
+
+// RequestRaw makes an authenticated HTTP request with a body of any content type.
+// Idempotent requests are retried with jittered exponential backoff on network
+// errors and server errors, and any request is retried when rate limited,
+// waiting as long as Retry-After asks. Cancelling ctx aborts the request,
+// including any wait before a retry.
+func (c *APIClient) RequestRaw(ctx context.Context, method, endpoint, contentType string, body io.Reader) (*http.Response, error) {
+	return c.request(ctx, method, endpoint, contentType, body, idempotent(method))
+}
+
+// RequestOnce is RequestRaw for a request that must not be sent twice, such
+// as one creating something that cannot be overwritten. It is only retried
+// when rate limited, since after a network or server error the registry may
+// have acted on it even though no response arrived.
+func (c *APIClient) RequestOnce(ctx context.Context, method, endpoint, contentType string, body io.Reader) (*http.Response, error) {
+	return c.request(ctx, method, endpoint, contentType, body, false)
+}
+
+// request sends a request, retrying it as RequestRaw describes; repeatable
+// says whether it may be retried after network and server errors.
+func (c *APIClient) request(ctx context.Context, method, endpoint, contentType string, body io.Reader, repeatable bool) (*http.Response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	url := c.config.GetAPIURL(endpoint)
+
+	// Buffer the body so it can be sent again
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = io.ReadAll(body); err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
 	}
 
 	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "promptbucket-cli")
+	headers, err := c.Headers(contentType)
+	if err != nil {
+		return nil, err
+	}
 
-	// Add auth headers if authenticated
-	if c.tokenManager.IsAuthenticated() {
-		authHeaders, err := c.tokenManager.GetAuthHeaders()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get auth headers: %w", err)
-		}
-		for key, value := range authHeaders {
-			req.Header.Set(key, value)
-		}
+	// Create request
+	var requestBody io.Reader
+	if payload != nil {
+		requestBody = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	req.Header = headers
 
 	// Make request
-	resp, err := c.httpClient.Do(req)
+	resp, err := send(c.httpClient, req, c.config.MaxRetries, repeatable)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
 
 	// Handle 401 Unauthorized - token may be expired
 	if resp.StatusCode == 401 && c.tokenManager.IsAuthenticated() {
 		// Clear expired token
+		resp.Body.Close()
 		c.tokenManager.ClearToken()
-		return nil, fmt.Errorf("authentication failed - please run 'promptbucket login' to re-authenticate")
+		return nil, fmt.Errorf("authentication failed - please run '%s' to re-authenticate", c.config.LoginCommand())
 	}
 
 	return resp, nil
 }
 
+// Headers returns the headers sent with a request body of contentType,
+// including authentication if logged in
+func (c *APIClient) Headers(contentType string) (http.Header, error) {
+	headers := http.Header{}
+	headers.Set("Content-Type", contentType)
+	headers.Set("User-Agent", "promptbucket-cli")
+
+	// Add auth headers if authenticated
+	if c.tokenManager.IsAuthenticated() {
+		authHeaders, err := c.tokenManager.GetAuthHeaders()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get auth headers: %w", err)
+		}
+		for key, value := range authHeaders {
+			headers.Set(key, value)
+		}
+	}
+	return headers, nil
+}
+
 // Get makes a GET request
-func (c *APIClient) Get(endpoint string) (*http.Response, error) {
-	return c.Request("GET", endpoint, nil)
+func (c *APIClient) Get(ctx context.Context, endpoint string) (*http.Response, error) {
+	return c.Request(ctx, "GET", endpoint, nil)
 }
 
 // Post makes a POST request
-func (c *APIClient) Post(endpoint string, body interface{}) (*http.Response, error) {
-	return c.Request("POST", endpoint, body)
+func (c *APIClient) Post(ctx context.Context, endpoint string, body interface{}) (*http.Response, error) {
+	return c.Request(ctx, "POST", endpoint, body)
 }
 
 // Put makes a PUT request
-func (c *APIClient) Put(endpoint string, body interface{}) (*http.Response, error) {
-	return c.Request("PUT", endpoint, body)
+func (c *APIClient) Put(ctx context.Context, endpoint string, body interface{}) (*http.Response, error) {
+	return c.Request(ctx, "PUT", endpoint, body)
 }
 
 // Delete makes a DELETE request
-func (c *APIClient) Delete(endpoint string) (*http.Response, error) {
-	return c.Request("DELETE", endpoint, nil)
+func (c *APIClient) Delete(ctx context.Context, endpoint string) (*http.Response, error) {
+	return c.Request(ctx, "DELETE", endpoint, nil)
 }
 
 // IsAuthenticated checks if the client is authenticated
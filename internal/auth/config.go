@@ -1,33 +1,58 @@
 package auth
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
 	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
+// ConfigFile holds user settings in the configuration directory.
+const ConfigFile = "config.yaml"
+
 // Config holds auth configuration
 type Config struct {
+	// Registry is the name of the registry BaseURL points at
+	Registry   string
 	BaseURL    string
 	APIVersion string
 	CallbackPort    int
 	ConfigDir       string
 	TokenFile       string
+	// TokenEnv names an environment variable holding an access token to use
+	// instead of the token file
+	TokenEnv string
+	// MaxRetries is how many times a failed request is retried
+	MaxRetries int
+	// Timeout bounds each attempt of a request
+	Timeout time.Duration
 }
 
-// NewConfig creates a new auth config with defaults
+// NewConfig creates a new auth config with defaults, for the registry
+// selected with SetRegistry
 func NewConfig() *Config {
 	homeDir, _ := os.UserHomeDir()
 	configDir := filepath.Join(homeDir, ".promptbucket")
 	
-	return &Config{
+	config := &Config{
+		Registry:   DefaultRegistry,
 		BaseURL:    getEnvOrDefault("PROMPTBUCKET_BASE_URL", "https://harbor.promptbucket.co"),
 		APIVersion: getEnvOrDefault("PROMPTBUCKET_API_VERSION", "v1"),
 		CallbackPort:    3456,
 		ConfigDir:       configDir,
 		TokenFile:       "token.json",
+		MaxRetries:      maxRetries,
+		Timeout:         timeout,
+	}
+	if selectedRegistry != "" {
+		// An unknown registry is reported when it is selected
+		config.UseRegistry(selectedRegistry)
 	}
+	return config
 }
 
 // GetAPIURL returns the full API URL
@@ -49,11 +74,40 @@ func (c *Config) GetOAuthURL(provider string) string {
 	return oauthURL + "?redirect_uri=" + url.QueryEscape(callbackURL)
 }
 
+// LoginCommand returns the command that logs in to the configured registry.
+func (c *Config) LoginCommand() string {
+	if c.Registry != "" && c.Registry != DefaultRegistry {
+		return "promptbucket login --registry " + c.Registry
+	}
+	return "promptbucket login"
+}
+
 // GetTokenPath returns the full path to the token file
 func (c *Config) GetTokenPath() string {
 	return filepath.Join(c.ConfigDir, c.TokenFile)
 }
 
+// configFile is the content of ConfigFile.
+type configFile struct {
+	Network    Network    `yaml:"network"`
+	Registries []Registry `yaml:"registries"`
+}
+
+// readConfigFile reads ConfigFile from the configuration directory; a
+// missing file is empty.
+func (c *Config) readConfigFile() (*configFile, error) {
+	var file configFile
+	path := filepath.Join(c.ConfigDir, ConfigFile)
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &file, nil
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Network configures how the CLI connects to the registry and to manifest
+// URLs. It is read from the network section of the config file, and each
+// setting can be overridden by an environment variable:
+//
+//	network:
+//	  proxy: http://proxy.example.com:8080      # PROMPTBUCKET_PROXY
+//	  no_proxy: [localhost, .internal.example]  # PROMPTBUCKET_NO_PROXY, comma separated
+//	  ca_files: [/etc/ssl/corp-root.pem]        # PROMPTBUCKET_CA_FILES, path list
+//	  client_cert: ~/.promptbucket/client.crt   # PROMPTBUCKET_CLIENT_CERT
+//	  client_key: ~/.promptbucket/client.key    # PROMPTBUCKET_CLIENT_KEY
+//	  insecure_skip_verify: false               # PROMPTBUCKET_INSECURE_SKIP_VERIFY
+//
+// Without a proxy setting, the standard HTTPS_PROXY, HTTP_PROXY and NO_PROXY
+// variables apply.
+type Network struct {
+	Proxy   string   `yaml:"proxy"`
+	NoProxy []string `yaml:"no_proxy"`
+	// CAFiles are PEM bundles trusted in addition to the system roots
+	CAFiles    []string `yaml:"ca_files"`
+	ClientCert string   `yaml:"client_cert"`
+	ClientKey  string   `yaml:"client_key"`
+	// InsecureSkipVerify disables certificate verification, for testing
+	// against a local registry only
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+}
+
+// Network reads the network settings from the config file and environment.
+func (c *Config) Network() (*Network, error) {
+	file, err := c.readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	n := &file.Network
+
+	if v := os.Getenv("PROMPTBUCKET_PROXY"); v != "" {
+		n.Proxy = v
+	}
+	if v := os.Getenv("PROMPTBUCKET_NO_PROXY"); v != "" {
+		n.NoProxy = strings.Split(v, ",")
+	}
+	if v := os.Getenv("PROMPTBUCKET_CA_FILES"); v != "" {
+		n.CAFiles = filepath.SplitList(v)
+	}
+	if v := os.Getenv("PROMPTBUCKET_CLIENT_CERT"); v != "" {
+		n.ClientCert = v
+	}
+	if v := os.Getenv("PROMPTBUCKET_CLIENT_KEY"); v != "" {
+		n.ClientKey = v
+	}
+	if v := os.Getenv("PROMPTBUCKET_INSECURE_SKIP_VERIFY"); v != "" {
+		insecure, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PROMPTBUCKET_INSECURE_SKIP_VERIFY %q: expected true or false", v)
+		}
+		n.InsecureSkipVerify = insecure
+	}
+	return n, nil
+}
+
+// Transport returns an HTTP transport that uses the network settings.
+func (n *Network) Transport() (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	if n.Proxy != "" {
+		proxy, err := parseProxy(n.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		t.Proxy = func(req *http.Request) (*url.URL, error) {
+			if bypassProxy(req.URL, n.NoProxy) {
+				return nil, nil
+			}
+			return proxy, nil
+		}
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: n.InsecureSkipVerify}
+	if len(n.CAFiles) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		for _, file := range n.CAFiles {
+			pem, err := os.ReadFile(expandHome(file))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("CA bundle %s contains no PEM certificates", file)
+			}
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if n.ClientCert != "" || n.ClientKey != "" {
+		if n.ClientCert == "" || n.ClientKey == "" {
+			return nil, fmt.Errorf("a client certificate needs both client_cert and client_key")
+		}
+		cert, err := tls.LoadX509KeyPair(expandHome(n.ClientCert), expandHome(n.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	t.TLSClientConfig = tlsConfig
+	return t, nil
+}
+
+// parseProxy parses a proxy URL, assuming http:// when no scheme is given.
+func parseProxy(raw string) (*url.URL, error) {
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q", raw)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy %q: scheme must be http, https or socks5", raw)
+	}
+	return u, nil
+}
+
+// bypassProxy reports whether a request to u goes direct. Each rule is "*",
+// a host name that also matches its subdomains (with or without a leading
+// dot), an IP address or a CIDR range, optionally followed by ":port".
+func bypassProxy(u *url.URL, rules []string) bool {
+	host := u.Hostname()
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+	}
+	ip := net.ParseIP(host)
+
+	for _, rule := range rules {
+		rule = strings.ToLower(strings.TrimSpace(rule))
+		switch {
+		case rule == "":
+			continue
+		case rule == "*":
+			return true
+		}
+
+		// CIDR ranges cannot carry a port
+		if _, network, err := net.ParseCIDR(rule); err == nil {
+			if ip != nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if h, p, err := net.SplitHostPort(rule); err == nil {
+			if p != port {
+				continue
+			}
+			rule = h
+		}
+		if ruleIP := net.ParseIP(strings.Trim(rule, "[]")); ruleIP != nil {
+			if ip != nil && ruleIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+		domain := strings.TrimPrefix(rule, ".")
+		if h := strings.ToLower(host); h == domain || strings.HasSuffix(h, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandHome replaces a leading ~ in a path with the home directory.
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
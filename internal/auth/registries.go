@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// DefaultRegistry names the registry at PROMPTBUCKET_BASE_URL, used unless a
+// scope rule or --registry picks another.
+const DefaultRegistry = "default"
+
+// Registry is a registry the CLI can talk to. Registries other than the
+// default are listed in the config file:
+//
+//	registries:
+//	  - name: internal
+//	    url: https://prompts.internal.example
+//	    scopes: ["acme/*"]
+//	    token_env: INTERNAL_PROMPTBUCKET_TOKEN
+type Registry struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	// Scopes are org/name patterns, such as "acme/*", for packages this
+	// registry serves
+	Scopes []string `yaml:"scopes"`
+	// TokenFile is where login saves credentials for this registry, in the
+	// configuration directory; it defaults to token-<name>.json
+	TokenFile string `yaml:"token_file"`
+	// TokenEnv names an environment variable holding an access token to use
+	// instead of logging in
+	TokenEnv string `yaml:"token_env"`
+}
+
+// registryNamePattern matches valid registry names.
+var registryNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// selectedRegistry is the registry chosen with SetRegistry.
+var selectedRegistry string
+
+// SetRegistry selects the registry every request goes to, overriding scope
+// rules. An empty name restores routing by scope.
+func SetRegistry(name string) error {
+	if name != "" {
+		if _, err := ConfigForRegistry(name); err != nil {
+			return err
+		}
+	}
+	selectedRegistry = name
+	return nil
+}
+
+// Registries returns the default registry followed by those in the config
+// file, in order.
+func (c *Config) Registries() ([]Registry, error) {
+	file, err := c.readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	registries := []Registry{{
+		Name:      DefaultRegistry,
+		URL:       getEnvOrDefault("PROMPTBUCKET_BASE_URL", "https://harbor.promptbucket.co"),
+		TokenFile: "token.json",
+	}}
+	seen := map[string]bool{DefaultRegistry: true}
+	for _, r := range file.Registries {
+		if err := checkRegistry(r); err != nil {
+			return nil, fmt.Errorf("%s: %w", ConfigFile, err)
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf("%s: registry %q is defined more than once", ConfigFile, r.Name)
+		}
+		seen[r.Name] = true
+		if r.TokenFile == "" {
+			r.TokenFile = "token-" + r.Name + ".json"
+		}
+		registries = append(registries, r)
+	}
+	return registries, nil
+}
+
+// checkRegistry validates a registry from the config file.
+func checkRegistry(r Registry) error {
+	switch {
+	case r.Name == DefaultRegistry:
+		return fmt.Errorf("registry name %q is reserved; set PROMPTBUCKET_BASE_URL to change it", DefaultRegistry)
+	case !registryNamePattern.MatchString(r.Name):
+		return fmt.Errorf("invalid registry name %q: use lowercase letters, digits, '-' and '_'", r.Name)
+	}
+	u, err := url.Parse(r.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("registry %s: invalid url %q", r.Name, r.URL)
+	}
+	for _, scope := range r.Scopes {
+		org, name, ok := strings.Cut(scope, "/")
+		_, orgErr := path.Match(org, "")
+		_, nameErr := path.Match(name, "")
+		if !ok || org == "" || name == "" || orgErr != nil || nameErr != nil {
+			return fmt.Errorf("registry %s: invalid scope %q (expected a pattern such as acme/*)", r.Name, scope)
+		}
+	}
+	return nil
+}
+
+// UseRegistry points the config at the named registry.
+func (c *Config) UseRegistry(name string) error {
+	registries, err := c.Registries()
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, r := range registries {
+		if r.Name == name {
+			c.Registry = r.Name
+			c.BaseURL = r.URL
+			c.TokenFile = r.TokenFile
+			c.TokenEnv = r.TokenEnv
+			return nil
+		}
+		names = append(names, r.Name)
+	}
+	return fmt.Errorf("unknown registry %q (configured: %s)", name, strings.Join(names, ", "))
+}
+
+// ConfigForRegistry returns the config for the named registry.
+func ConfigForRegistry(name string) (*Config, error) {
+	config := NewConfig()
+	if err := config.UseRegistry(name); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// ConfigForPackage returns the config for the registry that serves
+// org/name: the one selected with SetRegistry, else the first whose scopes
+// match, else the default. An empty name asks about the whole org, which
+// only scopes covering every package in it match.
+func ConfigForPackage(org, name string) *Config {
+	config := NewConfig()
+	if selectedRegistry != "" {
+		return config
+	}
+	// Configuration errors are reported before any command runs
+	registries, _ := config.Registries()
+	for _, r := range registries {
+		if r.InScope(org, name) {
+			config.UseRegistry(r.Name)
+			break
+		}
+	}
+	return config
+}
+
+// InScope reports whether one of the registry's scopes matches org/name.
+// An empty name matches scopes covering the whole org.
+func (r Registry) InScope(org, name string) bool {
+	for _, scope := range r.Scopes {
+		orgPattern, namePattern, _ := strings.Cut(scope, "/")
+		if ok, _ := path.Match(orgPattern, org); !ok {
+			continue
+		}
+		if name == "" {
+			if namePattern == "*" {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(namePattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
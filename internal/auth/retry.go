@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Request retry defaults, used unless SetRetries or SetTimeout change them
+const (
+	DefaultMaxRetries = 3
+	DefaultTimeout    = 30 * time.Second
+)
+
+const (
+	// retryBaseDelay is the backoff before the first retry; it doubles with
+	// each attempt up to retryMaxDelay.
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 10 * time.Second
+	// maxRetryAfter is the longest Retry-After the client waits out; longer
+	// ones are reported to the user instead.
+	maxRetryAfter = 60 * time.Second
+)
+
+var (
+	maxRetries = DefaultMaxRetries
+	timeout    = DefaultTimeout
+	// transport is shared by every request, so the network settings are
+	// only read once; nil until SetTransport is called
+	transport http.RoundTripper
+)
+
+// SetRetries sets how many times configs created afterwards retry a failed
+// request.
+func SetRetries(n int) {
+	maxRetries = n
+}
+
+// SetTimeout sets the per-attempt timeout of configs created afterwards.
+func SetTimeout(d time.Duration) {
+	timeout = d
+}
+
+// SetTransport sets the transport that API clients created afterwards and
+// Send use, such as one that goes through a proxy or trusts extra
+// certificate authorities.
+func SetTransport(t http.RoundTripper) {
+	transport = t
+}
+
+// Send sends a request that needs no authentication, such as a download
+// from a manifest URL, with the same transport, timeout and retry policy as
+// API requests. req must be created with http.NewRequestWithContext so that
+// its body can be sent again.
+func Send(req *http.Request) (*http.Response, error) {
+	client := &http.Client{Timeout: timeout, Transport: transport}
+	return send(client, req, maxRetries, idempotent(req.Method))
+}
+
+// RateLimitError is returned when the registry keeps rate limiting a
+// request after every retry, or asks for a longer wait than the client
+// will sleep through.
+type RateLimitError struct {
+	// RetryAfter is how long the registry asked to wait, zero if it did not
+	// say.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter <= 0 {
+		return "rate limited by the registry, retry later"
+	}
+	return fmt.Sprintf("rate limited by the registry, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// IsRateLimited reports whether err is a RateLimitError.
+func IsRateLimited(err error) bool {
+	var e *RateLimitError
+	return errors.As(err, &e)
+}
+
+// idempotent reports whether a request with method can be sent again
+// without changing its effect.
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryable reports whether a response status is worth retrying. A 429 was
+// rejected before being processed, so any request may be retried; other
+// server errors only repeatable ones.
+func retryable(repeatable bool, status int) bool {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return true
+	case status >= 500 && status != http.StatusNotImplemented:
+		return repeatable
+	}
+	return false
+}
+
+// backoff returns the jittered wait before retry number attempt (from 0):
+// a random duration between half and all of an exponentially growing delay.
+func backoff(attempt int) time.Duration {
+	d := retryBaseDelay << attempt
+	if d > retryMaxDelay || d <= 0 {
+		d = retryMaxDelay
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// sleep waits for d, returning early with ctx's error if it is cancelled.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// retryAfter parses a Retry-After header, given in seconds or as an HTTP
+// date, returning zero if absent or invalid.
+func retryAfter(resp *http.Response) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// send sends req with client, retrying up to retries times with jittered
+// exponential backoff on network and server errors if repeatable, and after
+// a 429 whatever the method, waiting as long as Retry-After asks. Waits end
+// early when the request's context is cancelled.
+func send(client *http.Client, req *http.Request, retries int, repeatable bool) (*http.Response, error) {
+	ctx := req.Context()
+	url := req.URL.Redacted()
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 {
+			r = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, fmt.Errorf("failed to create request: %w", err)
+				}
+				r.Body = body
+			}
+		}
+
+		resp, err := client.Do(r)
+		last := attempt >= retries
+		if err != nil {
+			if last || !repeatable || ctx.Err() != nil {
+				return nil, fmt.Errorf("request failed: %w", err)
+			}
+			wait := backoff(attempt)
+			fmt.Fprintf(os.Stderr, "⚠️  Request to %s failed (%v); retrying in %s\n", url, err, wait.Round(100*time.Millisecond))
+			if err := sleep(ctx, wait); err != nil {
+				return nil, fmt.Errorf("request failed: %w", err)
+			}
+			continue
+		}
+		if !retryable(repeatable, resp.StatusCode) {
+			return resp, nil
+		}
+
+		// Wait as long as the registry asks, within reason
+		wait := backoff(attempt)
+		asked := time.Duration(0)
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+			if asked = retryAfter(resp); asked > 0 {
+				wait = asked
+			}
+		}
+		if last || asked > maxRetryAfter {
+			if resp.StatusCode == http.StatusTooManyRequests {
+				resp.Body.Close()
+				return nil, &RateLimitError{RetryAfter: asked}
+			}
+			return resp, nil
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusTooManyRequests {
+			fmt.Fprintf(os.Stderr, "⏳ Rate limited by the registry; retrying in %s\n", wait.Round(100*time.Millisecond))
+		} else {
+			fmt.Fprintf(os.Stderr, "⚠️  Registry returned %d; retrying in %s\n", resp.StatusCode, wait.Round(100*time.Millisecond))
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+	}
+}
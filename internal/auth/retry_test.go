@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{0, 250 * time.Millisecond, 500 * time.Millisecond},
+		{1, 500 * time.Millisecond, time.Second},
+		{2, time.Second, 2 * time.Second},
+		{4, 4 * time.Second, 8 * time.Second},
+		{5, 5 * time.Second, 10 * time.Second},
+		{20, 5 * time.Second, 10 * time.Second},
+		// Large attempts overflow the shift and must still be capped
+		{70, 5 * time.Second, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			if d := backoff(tt.attempt); d < tt.min || d > tt.max {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", tt.attempt, d, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 100; i++ {
+		seen[backoff(3)] = true
+	}
+	if len(seen) < 10 {
+		t.Errorf("backoff(3) returned %d distinct waits in 100 calls, want them spread out", len(seen))
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	future := time.Now().Add(90 * time.Second).UTC().Format(http.TimeFormat)
+	past := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+
+	tests := []struct {
+		name     string
+		value    string
+		min, max time.Duration
+	}{
+		{"absent", "", 0, 0},
+		{"seconds", "120", 120 * time.Second, 120 * time.Second},
+		{"zero seconds", "0", 0, 0},
+		{"negative seconds", "-5", 0, 0},
+		{"fractional seconds", "1.5", 0, 0},
+		{"garbage", "soon", 0, 0},
+		{"http date", future, 80 * time.Second, 90 * time.Second},
+		{"http date in the past", past, 0, 0},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.value != "" {
+			resp.Header.Set("Retry-After", tt.value)
+		}
+		if d := retryAfter(resp); d < tt.min || d > tt.max {
+			t.Errorf("%s: retryAfter(%q) = %s, want between %s and %s", tt.name, tt.value, d, tt.min, tt.max)
+		}
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		method string
+		status int
+		want   bool
+	}{
+		{http.MethodGet, http.StatusOK, false},
+		{http.MethodGet, http.StatusNotFound, false},
+		{http.MethodGet, http.StatusInternalServerError, true},
+		{http.MethodGet, http.StatusBadGateway, true},
+		{http.MethodGet, http.StatusNotImplemented, false},
+		{http.MethodPut, http.StatusServiceUnavailable, true},
+		{http.MethodDelete, http.StatusGatewayTimeout, true},
+		{http.MethodPost, http.StatusInternalServerError, false},
+		{http.MethodPatch, http.StatusBadGateway, false},
+		{http.MethodPost, http.StatusTooManyRequests, true},
+		{http.MethodGet, http.StatusTooManyRequests, true},
+	}
+
+	for _, tt := range tests {
+		if got := retryable(idempotent(tt.method), tt.status); got != tt.want {
+			t.Errorf("retryable(%s, %d) = %v, want %v", tt.method, tt.status, got, tt.want)
+		}
+	}
+}
+
+// TestRequestOnce checks that a request that must not be repeated is only
+// retried when rate limited.
+func TestRequestOnce(t *testing.T) {
+	tests := []struct {
+		name     string
+		once     bool
+		statuses []int
+		want     int
+		requests int32
+	}{
+		{"put is retried", false, []int{500, 201}, 201, 2},
+		{"once is not retried", true, []int{500, 201}, 500, 1},
+		{"once is retried when rate limited", true, []int{429, 201}, 201, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&requests, 1)
+				if body, _ := io.ReadAll(r.Body); string(body) != "body" {
+					t.Errorf("request %d body = %q, want %q", n, body, "body")
+				}
+				status := tt.statuses[len(tt.statuses)-1]
+				if int(n) <= len(tt.statuses) {
+					status = tt.statuses[n-1]
+				}
+				if status == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", "0")
+				}
+				w.WriteHeader(status)
+			}))
+			defer server.Close()
+
+			client := NewAPIClient(&Config{
+				BaseURL:    server.URL,
+				APIVersion: "v1",
+				ConfigDir:  t.TempDir(),
+				TokenFile:  "token.json",
+				MaxRetries: 3,
+				Timeout:    5 * time.Second,
+			})
+			send := client.RequestRaw
+			if tt.once {
+				send = client.RequestOnce
+			}
+			resp, err := send(context.Background(), http.MethodPut, "/thing", "text/plain", strings.NewReader("body"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if got := atomic.LoadInt32(&requests); got != tt.requests {
+				t.Errorf("server saw %d request(s), want %d", got, tt.requests)
+			}
+		})
+	}
+}
+
+func TestSend(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("manifest"))
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequestWithContext(context.Background(), "GET", server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := Send(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || atomic.LoadInt32(&requests) != 2 {
+		t.Errorf("status = %d after %d request(s), want 200 after a retry", resp.StatusCode, requests)
+	}
+}
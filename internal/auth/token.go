@@ -52,6 +52,13 @@ func (tm *TokenManager) SaveToken(token *Token) error {
 
 // GetToken retrieves the stored auth token
 func (tm *TokenManager) GetToken() (*Token, error) {
+	// A token from the environment takes the place of logging in
+	if tm.config.TokenEnv != "" {
+		if value := os.Getenv(tm.config.TokenEnv); value != "" {
+			return &Token{AccessToken: value}, nil
+		}
+	}
+
 	tokenPath := tm.config.GetTokenPath()
 	
 	// Check if file exists
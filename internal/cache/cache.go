@@ -0,0 +1,249 @@
+// Package cache is a content-addressed store for downloaded manifests and
+// registry metadata, kept under ~/.promptbucket/cache.
+//
+// Content is stored once per digest in blobs/sha256/<hex>; entries in refs/
+// map a key, usually the URL it was downloaded from, to a digest along with
+// the bookkeeping needed for revalidation and pruning.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/promptbucket/cli/internal/atomicfile"
+)
+
+// ErrOffline is returned when offline mode needs something that is not in
+// the cache.
+var ErrOffline = errors.New("not in the local cache and --offline is set")
+
+var offline bool
+
+// SetOffline turns offline mode on or off for the whole process. In offline
+// mode nothing is downloaded; reads are served from the cache or fail with
+// ErrOffline.
+func SetOffline(on bool) {
+	offline = on
+}
+
+// Offline reports whether offline mode is on.
+func Offline() bool {
+	return offline
+}
+
+// Cache is a cache directory.
+type Cache struct {
+	Dir string
+}
+
+// Entry describes one cached key.
+type Entry struct {
+	Key    string `json:"key"`
+	Digest string `json:"digest"`
+	Size   int64  `json:"size"`
+	// Immutable entries, such as published package versions, are served
+	// without revalidation.
+	Immutable bool `json:"immutable,omitempty"`
+	// ETag and LastModified are the validators from the HTTP response.
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastUsed     time.Time `json:"last_used"`
+}
+
+// Open returns the cache in dir. The directory is created on first write.
+func Open(dir string) *Cache {
+	return &Cache{Dir: dir}
+}
+
+// Default returns the cache in PROMPTBUCKET_CACHE_DIR, or
+// ~/.promptbucket/cache.
+func Default() *Cache {
+	homeDir, _ := os.UserHomeDir()
+	return InConfigDir(filepath.Join(homeDir, ".promptbucket"))
+}
+
+// InConfigDir returns the cache in PROMPTBUCKET_CACHE_DIR, or the "cache"
+// directory under a CLI configuration directory.
+func InConfigDir(configDir string) *Cache {
+	if dir := os.Getenv("PROMPTBUCKET_CACHE_DIR"); dir != "" {
+		return Open(dir)
+	}
+	return Open(filepath.Join(configDir, "cache"))
+}
+
+// Digest returns the "sha256:<hex>" digest of data.
+func Digest(data []byte) string {
+	hash := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(hash[:])
+}
+
+func (c *Cache) blobPath(digest string) (string, error) {
+	hexDigest, ok := strings.CutPrefix(digest, "sha256:")
+	if !ok || len(hexDigest) != sha256.Size*2 {
+		return "", fmt.Errorf("invalid digest %q", digest)
+	}
+	return filepath.Join(c.Dir, "blobs", "sha256", strings.ToLower(hexDigest)), nil
+}
+
+func (c *Cache) refPath(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, "refs", hex.EncodeToString(hash[:])+".json")
+}
+
+// Put stores data under key, replacing any previous entry.
+func (c *Cache) Put(key string, data []byte, e Entry) (Entry, error) {
+	e.Key = key
+	e.Digest = Digest(data)
+	e.Size = int64(len(data))
+	now := time.Now().UTC()
+	e.CreatedAt = now
+	e.LastUsed = now
+
+	blob, _ := c.blobPath(e.Digest)
+	if _, err := os.Stat(blob); err != nil {
+		if err := atomicfile.Write(blob, data, 0600); err != nil {
+			return e, fmt.Errorf("failed to write cache: %w", err)
+		}
+	}
+	if err := c.writeEntry(e); err != nil {
+		return e, err
+	}
+	return e, nil
+}
+
+// Lookup returns the entry for key without reading its content.
+func (c *Cache) Lookup(key string) (Entry, bool) {
+	data, err := os.ReadFile(c.refPath(key))
+	if err != nil {
+		return Entry{}, false
+	}
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil || e.Key != key {
+		return Entry{}, false
+	}
+	return e, true
+}
+
+// Get returns the content cached under key. Content that no longer matches
+// its digest is treated as missing.
+func (c *Cache) Get(key string) ([]byte, Entry, bool) {
+	e, ok := c.Lookup(key)
+	if !ok {
+		return nil, e, false
+	}
+	data, ok := c.Blob(e.Digest)
+	if !ok {
+		return nil, e, false
+	}
+	c.touch(e)
+	return data, e, true
+}
+
+// Blob returns the content with the given digest, if cached and intact.
+func (c *Cache) Blob(digest string) ([]byte, bool) {
+	blob, err := c.blobPath(digest)
+	if err != nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(blob)
+	if err != nil || Digest(data) != strings.ToLower(digest) {
+		return nil, false
+	}
+	return data, true
+}
+
+// touch records a cache hit for pruning. Failures are ignored; the entry is
+// still usable.
+func (c *Cache) touch(e Entry) {
+	e.LastUsed = time.Now().UTC()
+	c.writeEntry(e)
+}
+
+func (c *Cache) writeEntry(e Entry) error {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	if err := atomicfile.Write(c.refPath(e.Key), data, 0600); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	return nil
+}
+
+// Entries lists every entry, most recently used first.
+func (c *Cache) Entries() ([]Entry, error) {
+	files, err := filepath.Glob(filepath.Join(c.Dir, "refs", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cache: %w", err)
+		}
+		var e Entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			// Unreadable entries are reported by Verify and removed by Prune
+			continue
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].LastUsed.After(entries[j].LastUsed) })
+	return entries, nil
+}
+
+// Remove deletes the entry for key. Its content is left for Prune, since
+// other entries may share it.
+func (c *Cache) Remove(key string) error {
+	if err := os.Remove(c.refPath(key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove cache entry: %w", err)
+	}
+	return nil
+}
+
+// Clear deletes the whole cache and returns the number of bytes freed.
+func (c *Cache) Clear() (int64, error) {
+	size, err := c.Size()
+	if err != nil {
+		return 0, err
+	}
+	if err := os.RemoveAll(c.Dir); err != nil {
+		return 0, fmt.Errorf("failed to clear cache: %w", err)
+	}
+	return size, nil
+}
+
+// Size returns the bytes used on disk by cached content and entries.
+func (c *Cache) Size() (int64, error) {
+	var size int64
+	err := filepath.WalkDir(c.Dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure cache: %w", err)
+	}
+	return size, nil
+}
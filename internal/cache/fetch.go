@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+
+	"github.com/promptbucket/cli/internal/auth"
+)
+
+// manifestURLRe matches registry manifest URLs, whose content never changes
+// once a version is published.
+var manifestURLRe = regexp.MustCompile(`/v\d+/manifests/[^/?#]+/[^/?#]+/[^/?#]+$`)
+
+// IsImmutableURL reports whether url names content that never changes, so
+// a cached copy can be used without asking the server.
+func IsImmutableURL(url string) bool {
+	return manifestURLRe.MatchString(url)
+}
+
+// Fetch downloads url through the cache. Immutable URLs are served from the
+// cache when present; others are revalidated with the server's ETag or
+// Last-Modified, so unchanged content is not downloaded again. Downloads use
+// the transport, timeout and retries configured in the auth package. In
+// offline mode only the cache is consulted. Cancelling ctx aborts the
+// download.
+func (c *Cache) Fetch(ctx context.Context, url string) ([]byte, error) {
+	data, e, ok := c.Get(url)
+	if offline {
+		if !ok {
+			return nil, fmt.Errorf("%s is not cached: %w", url, ErrOffline)
+		}
+		return data, nil
+	}
+	if ok && e.Immutable {
+		return data, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "promptbucket-cli")
+	if ok {
+		if e.ETag != "" {
+			req.Header.Set("If-None-Match", e.ETag)
+		}
+		if e.LastModified != "" {
+			req.Header.Set("If-Modified-Since", e.LastModified)
+		}
+	}
+
+	resp, err := auth.Send(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && ok {
+		return data, nil
+	}
+
+	// Read response
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from %s: %w", url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: status %d", url, resp.StatusCode)
+	}
+
+	// A cache that cannot be written only costs a later download
+	c.Put(url, body, Entry{
+		Immutable:    IsImmutableURL(url),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	})
+	return body, nil
+}
@@ -0,0 +1,162 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Default limits applied by Prune when none are given.
+const (
+	DefaultMaxAge  = 30 * 24 * time.Hour
+	DefaultMaxSize = 256 << 20
+)
+
+// Problem is an inconsistency found by Verify.
+type Problem struct {
+	// Path is the file at fault.
+	Path string
+	// Key is the affected entry's key, if known.
+	Key     string
+	Message string
+}
+
+// Verify re-hashes every cached blob and checks that every entry points at
+// intact content.
+func (c *Cache) Verify() ([]Problem, error) {
+	var problems []Problem
+
+	blobs, err := filepath.Glob(filepath.Join(c.Dir, "blobs", "sha256", "*"))
+	if err != nil {
+		return nil, err
+	}
+	intact := map[string]bool{}
+	for _, blob := range blobs {
+		if strings.HasPrefix(filepath.Base(blob), ".tmp-") {
+			continue
+		}
+		data, err := os.ReadFile(blob)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cache: %w", err)
+		}
+		want := "sha256:" + filepath.Base(blob)
+		if got := Digest(data); got != want {
+			problems = append(problems, Problem{Path: blob, Message: fmt.Sprintf("content hashes to %s", got)})
+			continue
+		}
+		intact[want] = true
+	}
+
+	refs, err := filepath.Glob(filepath.Join(c.Dir, "refs", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range refs {
+		data, err := os.ReadFile(ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cache: %w", err)
+		}
+		var e Entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			problems = append(problems, Problem{Path: ref, Message: "unreadable entry"})
+			continue
+		}
+		if !intact[e.Digest] {
+			problems = append(problems, Problem{Path: ref, Key: e.Key, Message: fmt.Sprintf("content %s is missing or corrupt", e.Digest)})
+		}
+	}
+	return problems, nil
+}
+
+// PruneOptions are the limits Prune enforces. Zero values disable a limit.
+type PruneOptions struct {
+	// MaxAge removes entries not used for longer than this.
+	MaxAge time.Duration
+	// MaxSize removes the least recently used entries until the cached
+	// content fits.
+	MaxSize int64
+}
+
+// PruneResult reports what Prune removed.
+type PruneResult struct {
+	Removed []Entry
+	// Broken counts entries dropped because their content was missing or
+	// corrupt, or because they could not be read.
+	Broken int
+	Freed  int64
+}
+
+// Prune removes broken entries and entries outside the limits, then deletes
+// content no remaining entry refers to.
+func (c *Cache) Prune(opts PruneOptions) (PruneResult, error) {
+	var result PruneResult
+
+	// Drop whatever Verify objects to first
+	problems, err := c.Verify()
+	if err != nil {
+		return result, err
+	}
+	for _, p := range problems {
+		if err := os.Remove(p.Path); err != nil && !os.IsNotExist(err) {
+			return result, fmt.Errorf("failed to prune cache: %w", err)
+		}
+		if p.Key != "" || strings.HasSuffix(p.Path, ".json") {
+			result.Broken++
+		}
+	}
+
+	entries, err := c.Entries()
+	if err != nil {
+		return result, err
+	}
+
+	// Entries are most recently used first, so keep a prefix that fits
+	var kept []Entry
+	var size int64
+	counted := map[string]bool{}
+	now := time.Now()
+	for _, e := range entries {
+		expired := opts.MaxAge > 0 && now.Sub(e.LastUsed) > opts.MaxAge
+		grows := int64(0)
+		if !counted[e.Digest] {
+			grows = e.Size
+		}
+		if expired || (opts.MaxSize > 0 && size+grows > opts.MaxSize) {
+			if err := c.Remove(e.Key); err != nil {
+				return result, err
+			}
+			result.Removed = append(result.Removed, e)
+			continue
+		}
+		kept = append(kept, e)
+		counted[e.Digest] = true
+		size += grows
+	}
+
+	// Delete content that nothing refers to any more
+	referenced := map[string]bool{}
+	for _, e := range kept {
+		referenced[e.Digest] = true
+	}
+	blobs, err := filepath.Glob(filepath.Join(c.Dir, "blobs", "sha256", "*"))
+	if err != nil {
+		return result, err
+	}
+	for _, blob := range blobs {
+		if referenced["sha256:"+filepath.Base(blob)] {
+			continue
+		}
+		info, err := os.Stat(blob)
+		if err != nil {
+			continue
+		}
+		if err := os.Remove(blob); err != nil {
+			return result, fmt.Errorf("failed to prune cache: %w", err)
+		}
+		result.Freed += info.Size()
+	}
+	return result, nil
+}
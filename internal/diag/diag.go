@@ -0,0 +1,155 @@
+// Package diag defines the positioned diagnostics reported by manifest
+// checks and renders them for terminals, tools and code-scanning services.
+package diag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Severity is how serious a diagnostic is.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// ParseSeverity converts a configured severity name. "off" is returned as an
+// empty severity so callers can drop the diagnostic.
+func ParseSeverity(s string) (Severity, error) {
+	switch s {
+	case "error":
+		return SeverityError, nil
+	case "warning", "warn":
+		return SeverityWarning, nil
+	case "info", "note":
+		return SeverityInfo, nil
+	case "off":
+		return "", nil
+	default:
+		return "", fmt.Errorf("unknown severity %q (expected error, warning, info or off)", s)
+	}
+}
+
+// Diagnostic is a single finding tied to a location in a file.
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// RuleInfo describes a rule for formats that carry rule metadata.
+type RuleInfo struct {
+	ID          string
+	Description string
+}
+
+// Sort orders diagnostics by file, then position, then rule.
+func Sort(diags []Diagnostic) {
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i], diags[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+		return a.Rule < b.Rule
+	})
+}
+
+// Count returns how many diagnostics have the given severity.
+func Count(diags []Diagnostic, sev Severity) int {
+	n := 0
+	for _, d := range diags {
+		if d.Severity == sev {
+			n++
+		}
+	}
+	return n
+}
+
+// Formats lists the output formats accepted by Write.
+var Formats = []string{"text", "json", "github", "sarif"}
+
+// Write renders diagnostics in the requested format. tool names the
+// producing command in formats that record it.
+func Write(w io.Writer, format, tool string, rules []RuleInfo, diags []Diagnostic) error {
+	switch format {
+	case "", "text":
+		return writeText(w, diags)
+	case "json":
+		if diags == nil {
+			diags = []Diagnostic{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diags)
+	case "github":
+		return writeGitHub(w, diags)
+	case "sarif":
+		return writeSARIF(w, tool, rules, diags)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+func writeText(w io.Writer, diags []Diagnostic) error {
+	for _, d := range diags {
+		loc := d.File
+		if d.Line > 0 {
+			loc = fmt.Sprintf("%s:%d:%d", d.File, d.Line, d.Column)
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s: %s [%s]\n", loc, d.Severity, d.Message, d.Rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeGitHub emits GitHub Actions workflow commands, which the Actions runner
+// turns into annotations on the pull request diff.
+func writeGitHub(w io.Writer, diags []Diagnostic) error {
+	for _, d := range diags {
+		level := "notice"
+		switch d.Severity {
+		case SeverityError:
+			level = "error"
+		case SeverityWarning:
+			level = "warning"
+		}
+
+		props := []string{"file=" + escapeGitHubProperty(d.File)}
+		if d.Line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", d.Line))
+			if d.Column > 0 {
+				props = append(props, fmt.Sprintf("col=%d", d.Column))
+			}
+		}
+		props = append(props, "title="+escapeGitHubProperty(d.Rule))
+
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", level, strings.Join(props, ","), escapeGitHubData(d.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,72 @@
+package install
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/promptbucket/cli/internal/atomicfile"
+	"github.com/promptbucket/cli/internal/packager"
+)
+
+// Dir is where packages are installed, relative to the project.
+var Dir = filepath.Join(".promptbucket", "packages")
+
+// ManifestPath returns where an installed package's manifest lives.
+func ManifestPath(dir, org, name string) string {
+	return filepath.Join(dir, org, name, packager.ManifestFile)
+}
+
+// Change is what Install did with one package.
+type Change struct {
+	Package string
+	// Action is "added", "updated", "unchanged" or "removed".
+	Action  string
+	Version string
+}
+
+// Install writes the resolved packages into dir and removes packages that
+// are no longer needed.
+func Install(dir string, resolved []*Resolved) ([]Change, error) {
+	var changes []Change
+	keep := map[string]bool{}
+
+	for _, r := range resolved {
+		path := ManifestPath(dir, r.Org, r.Name)
+		keep[filepath.Dir(path)] = true
+
+		action := "added"
+		if existing, err := os.ReadFile(path); err == nil {
+			action = "updated"
+			if bytes.Equal(existing, r.Data) {
+				changes = append(changes, Change{Package: r.FullName(), Action: "unchanged", Version: r.Version})
+				continue
+			}
+		}
+		if err := atomicfile.Write(path, r.Data, 0644); err != nil {
+			return changes, fmt.Errorf("failed to install %s: %w", r.FullName(), err)
+		}
+		changes = append(changes, Change{Package: r.FullName(), Action: action, Version: r.Version})
+	}
+
+	// Remove packages nothing depends on any more
+	installed, err := filepath.Glob(filepath.Join(dir, "*", "*"))
+	if err != nil {
+		return changes, err
+	}
+	for _, pkgDir := range installed {
+		if keep[pkgDir] {
+			continue
+		}
+		if err := os.RemoveAll(pkgDir); err != nil {
+			return changes, fmt.Errorf("failed to remove %s: %w", pkgDir, err)
+		}
+		rel, _ := filepath.Rel(dir, pkgDir)
+		changes = append(changes, Change{Package: filepath.ToSlash(rel), Action: "removed"})
+
+		// Drop the org directory once it is empty
+		os.Remove(filepath.Dir(pkgDir))
+	}
+	return changes, nil
+}
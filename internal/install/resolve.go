@@ -0,0 +1,326 @@
+// Package install resolves a manifest's dependencies against the registry
+// and installs them into a project-local directory.
+package install
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/promptbucket/cli/internal/cache"
+	"github.com/promptbucket/cli/internal/packager"
+	"github.com/promptbucket/cli/internal/registry"
+	"github.com/promptbucket/cli/internal/semver"
+	"gopkg.in/yaml.v3"
+)
+
+// maxRounds bounds how often resolution may revise its choices before giving
+// up on a graph whose requirements keep shifting.
+const maxRounds = 20
+
+// Resolved is one package selected for installation.
+type Resolved struct {
+	Org     string
+	Name    string
+	Version string
+	Digest  string
+	// Data is the package's manifest as published.
+	Data []byte
+	// Manifest is Data flattened, for its own dependencies.
+	Manifest *packager.Manifest
+	// RequiredBy lists who asked for the package and with what range.
+	RequiredBy []string
+	// Status is the version as the registry lists it, including any
+	// deprecation or yank.
+	Status registry.Version
+}
+
+// FullName returns "org/name".
+func (r *Resolved) FullName() string {
+	return r.Org + "/" + r.Name
+}
+
+// Resolver selects one version of every package in a dependency graph.
+type Resolver struct {
+	// ClientFor returns the client for the registry that serves a package.
+	ClientFor func(org, name string) *registry.Client
+	// Lock holds previously pinned versions, preferred while they still
+	// satisfy every requirement. May be nil.
+	Lock *packager.Lock
+	// Update ignores pinned versions and picks the newest that fit.
+	Update bool
+
+	packages map[string]*registry.Package
+}
+
+type requirement struct {
+	by  string
+	raw string
+	rng semver.Range
+	// exact is the one version the requirement names, by "=version",
+	// "version" or a dist-tag, if it names one.
+	exact string
+}
+
+// Resolve selects a version for each package m depends on, directly or
+// through other packages, and downloads their manifests. Packages are
+// returned ordered by name.
+func (r *Resolver) Resolve(ctx context.Context, m *packager.Manifest) ([]*Resolved, error) {
+	r.packages = map[string]*registry.Package{}
+	selected := map[string]*Resolved{}
+
+	for round := 0; round < maxRounds; round++ {
+		// Collect requirements by walking the graph of current selections
+		reqs := map[string][]requirement{}
+		var order []string
+		visited := map[string]bool{}
+		var walk func(by string, deps map[string]string) error
+		walk = func(by string, deps map[string]string) error {
+			for _, key := range sortedKeys(deps) {
+				raw := strings.TrimSpace(deps[key])
+				if raw == "" {
+					raw = "*"
+				}
+				req, err := r.requirement(ctx, key, by, raw)
+				if err != nil {
+					return fmt.Errorf("%s: dependency %s: %w", by, key, err)
+				}
+				if _, seen := reqs[key]; !seen {
+					order = append(order, key)
+				}
+				reqs[key] = append(reqs[key], req)
+
+				if sel := selected[key]; sel != nil && !visited[key] {
+					visited[key] = true
+					if err := walk(key+"@"+sel.Version, sel.Manifest.Dependencies); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}
+		if err := walk(packager.ManifestFile, m.Dependencies); err != nil {
+			return nil, err
+		}
+
+		// Choose a version for everything required; a changed choice may
+		// change the requirements, so go round again
+		changed := len(reqs) != len(selected)
+		next := map[string]*Resolved{}
+		for _, key := range order {
+			version, err := r.choose(ctx, key, reqs[key])
+			if err != nil {
+				return nil, err
+			}
+			if prev := selected[key]; prev != nil && prev.Version == version.Tag {
+				next[key] = prev
+				continue
+			}
+			changed = true
+			sel, err := r.fetch(ctx, key, version)
+			if err != nil {
+				return nil, err
+			}
+			next[key] = sel
+		}
+		selected = next
+
+		if !changed {
+			var result []*Resolved
+			for _, key := range order {
+				sel := selected[key]
+				sel.RequiredBy = nil
+				for _, req := range reqs[key] {
+					sel.RequiredBy = append(sel.RequiredBy, fmt.Sprintf("%s (%s)", req.by, req.raw))
+				}
+				result = append(result, sel)
+			}
+			sort.Slice(result, func(i, j int) bool { return result[i].FullName() < result[j].FullName() })
+			return result, nil
+		}
+	}
+	return nil, fmt.Errorf("dependency resolution did not settle after %d rounds", maxRounds)
+}
+
+// requirement parses a dependency's range. A dist-tag such as "@beta" is
+// looked up in the registry and requires exactly the version it points at.
+func (r *Resolver) requirement(ctx context.Context, key, by, raw string) (requirement, error) {
+	req := requirement{by: by, raw: raw}
+	spec := raw
+	if strings.HasPrefix(raw, "@") {
+		pkg, err := r.pkg(ctx, key)
+		if err != nil {
+			return req, err
+		}
+		v, err := registry.ResolveTag(pkg, raw[1:])
+		if err != nil {
+			return req, err
+		}
+		spec = "=" + v.Tag
+		req.raw = raw + " → " + v.Tag
+	}
+
+	rng, err := semver.ParseRange(spec)
+	if err != nil {
+		return req, err
+	}
+	req.rng = rng
+	req.exact = strings.TrimSpace(strings.TrimPrefix(spec, "="))
+	return req, nil
+}
+
+// choose picks the version of a package that satisfies every requirement:
+// the pinned one if it still fits, otherwise the highest.
+func (r *Resolver) choose(ctx context.Context, key string, reqs []requirement) (registry.Version, error) {
+	pkg, err := r.pkg(ctx, key)
+	if err != nil {
+		return registry.Version{}, err
+	}
+
+	// Yanked versions are only used when pinned exactly, by the lockfile or
+	// by a requirement naming that one version
+	pinned, hasPin := r.Lock.Find(packager.LockKindDependency, key)
+	exactly := func(tag string) bool {
+		if hasPin && !r.Update && pinned.Version == tag {
+			return true
+		}
+		for _, req := range reqs {
+			if req.exact == tag {
+				return true
+			}
+		}
+		return false
+	}
+
+	byVersion := map[string]registry.Version{}
+	var fits []semver.Version
+	var available []string
+	for _, v := range pkg.Versions {
+		sv, err := semver.Parse(v.Tag)
+		if err != nil || (v.Yanked && !exactly(v.Tag)) {
+			continue
+		}
+		available = append(available, v.Tag)
+		ok := true
+		for _, req := range reqs {
+			if !req.rng.Contains(sv) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			byVersion[sv.String()] = v
+			fits = append(fits, sv)
+		}
+	}
+
+	if hasPin && !r.Update {
+		for _, sv := range fits {
+			if byVersion[sv.String()].Tag == pinned.Version {
+				return byVersion[sv.String()], nil
+			}
+		}
+	}
+	best, ok := semver.Max(fits)
+	if !ok {
+		var lines []string
+		for _, req := range reqs {
+			lines = append(lines, fmt.Sprintf("  %s requires %s", req.by, req.raw))
+		}
+		if len(available) == 0 {
+			available = []string{"none"}
+		}
+		return registry.Version{}, fmt.Errorf("no version of %s satisfies every requirement:\n%s\navailable: %s",
+			key, strings.Join(lines, "\n"), strings.Join(available, ", "))
+	}
+	return byVersion[best.String()], nil
+}
+
+// pkg returns a package's registry details, fetching them once.
+func (r *Resolver) pkg(ctx context.Context, key string) (*registry.Package, error) {
+	if pkg, ok := r.packages[key]; ok {
+		return pkg, nil
+	}
+	org, name, err := SplitName(key)
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := r.ClientFor(org, name).Package(ctx, org, name)
+	if registry.IsNotFound(err) {
+		return nil, fmt.Errorf("dependency %s not found in the registry", key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get package info for %s: %w", key, err)
+	}
+	r.packages[key] = pkg
+	return pkg, nil
+}
+
+// fetch downloads a selected version's manifest and checks it against the
+// registry's digest and any pinned digest.
+func (r *Resolver) fetch(ctx context.Context, key string, v registry.Version) (*Resolved, error) {
+	org, name, _ := SplitName(key)
+	data, err := r.ClientFor(org, name).Manifest(ctx, org, name, v.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s:%s: %w", key, v.Tag, err)
+	}
+
+	digest := cache.Digest(data)
+	if want := v.Digest; want != "" && !strings.HasPrefix(want, "sha256:") {
+		v.Digest = "sha256:" + want
+	}
+	if v.Digest != "" && !strings.EqualFold(v.Digest, digest) {
+		return nil, fmt.Errorf("digest mismatch for %s:%s: registry has %s, downloaded %s", key, v.Tag, v.Digest, digest)
+	}
+	if pinned, ok := r.Lock.Find(packager.LockKindDependency, key); ok && pinned.Version == v.Tag && pinned.Digest != digest {
+		return nil, fmt.Errorf("digest mismatch for %s:%s: %s has %s, downloaded %s", key, v.Tag, packager.LockFile, pinned.Digest, digest)
+	}
+
+	var m packager.Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse %s:%s: %w", key, v.Tag, err)
+	}
+	if err := packager.CheckCompatibility(&m); err != nil {
+		return nil, fmt.Errorf("%s:%s: %w", key, v.Tag, err)
+	}
+	flattened, err := packager.FlattenManifest(ctx, &m)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%s: %w", key, v.Tag, err)
+	}
+
+	return &Resolved{Org: org, Name: name, Version: v.Tag, Digest: digest, Data: data, Manifest: flattened, Status: v}, nil
+}
+
+// SplitName splits a dependency name into org and package name.
+func SplitName(key string) (string, string, error) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid dependency name %q (expected org/name)", key)
+	}
+	return parts[0], parts[1], nil
+}
+
+// LockEntries returns lock entries pinning the resolved packages.
+func LockEntries(resolved []*Resolved) []packager.LockEntry {
+	var entries []packager.LockEntry
+	for _, r := range resolved {
+		entries = append(entries, packager.LockEntry{
+			Kind:    packager.LockKindDependency,
+			Source:  r.FullName(),
+			Name:    r.Manifest.Name,
+			Version: r.Version,
+			Digest:  r.Digest,
+		})
+	}
+	return entries
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,244 @@
+package install
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/promptbucket/cli/internal/cache"
+	"github.com/promptbucket/cli/internal/packager"
+	"github.com/promptbucket/cli/internal/registry"
+	"github.com/promptbucket/cli/internal/registry/registrytest"
+)
+
+// publish adds org/name at version with the given dependencies.
+func publish(server *registrytest.Server, key, version string, deps ...string) {
+	org, name, _ := SplitName(key)
+	data := fmt.Sprintf("name: %s\nversion: %s\nprompt: Hello\n", name, version)
+	if len(deps) > 0 {
+		data += "dependencies:\n"
+		for i := 0; i < len(deps); i += 2 {
+			data += fmt.Sprintf("  %s: %q\n", deps[i], deps[i+1])
+		}
+	}
+	server.AddManifest(org, name, version, []byte(data))
+}
+
+func TestResolve(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+
+	// Oldest first, so the registry lists them newest first
+	publish(server, "acme/base", "1.0.0")
+	publish(server, "acme/base", "1.1.0")
+	publish(server, "acme/base", "2.0.0")
+	publish(server, "acme/base", "2.1.0")
+	publish(server, "acme/base", "3.0.0-beta.1")
+	server.SetDistTag("acme", "base", "beta", "3.0.0-beta.1")
+	publish(server, "acme/lib", "1.0.0", "acme/base", "^1")
+	publish(server, "acme/lib", "2.0.0", "acme/base", "^2")
+	publish(server, "acme/app", "1.0.0", "acme/base", "~1.0")
+
+	// Each choice of x and y changes what the other requires, so the
+	// selections never settle
+	publish(server, "acme/x", "1.0.0")
+	publish(server, "acme/x", "2.0.0", "acme/y", "^1")
+	publish(server, "acme/y", "1.0.0", "acme/x", "^1")
+	publish(server, "acme/y", "2.0.0")
+
+	client := server.Client(t.TempDir())
+	ctx := context.Background()
+	if err := client.Yank(ctx, "acme", "base", "2.1.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	pin := func(key, version string) packager.LockEntry {
+		org, name, _ := SplitName(key)
+		return packager.LockEntry{Kind: packager.LockKindDependency, Source: key, Name: name, Version: version,
+			Digest: cache.Digest(server.Manifest(org, name, version))}
+	}
+
+	tests := []struct {
+		name   string
+		deps   map[string]string
+		lock   []packager.LockEntry
+		update bool
+		want   map[string]string
+		err    string
+	}{
+		{
+			name: "highest in range",
+			deps: map[string]string{"acme/base": "^1"},
+			want: map[string]string{"acme/base": "1.1.0"},
+		},
+		{
+			name: "empty range skips yanked and pre-releases",
+			deps: map[string]string{"acme/base": ""},
+			want: map[string]string{"acme/base": "2.0.0"},
+		},
+		{
+			name: "transitive requirement narrows the choice",
+			deps: map[string]string{"acme/app": "*", "acme/base": "^1"},
+			want: map[string]string{"acme/app": "1.0.0", "acme/base": "1.0.0"},
+		},
+		{
+			name: "transitive dependency is added",
+			deps: map[string]string{"acme/lib": "^1"},
+			want: map[string]string{"acme/lib": "1.0.0", "acme/base": "1.1.0"},
+		},
+		{
+			name: "transitive conflict",
+			deps: map[string]string{"acme/app": "*", "acme/lib": "^2"},
+			err:  "no version of acme/base satisfies every requirement:\n  acme/app@1.0.0 requires ~1.0\n  acme/lib@2.0.0 requires ^2\navailable: 3.0.0-beta.1, 2.0.0, 1.1.0, 1.0.0",
+		},
+		{
+			name: "pinned version is kept",
+			deps: map[string]string{"acme/base": "^1"},
+			lock: []packager.LockEntry{pin("acme/base", "1.0.0")},
+			want: map[string]string{"acme/base": "1.0.0"},
+		},
+		{
+			name:   "update ignores the pin",
+			deps:   map[string]string{"acme/base": "^1"},
+			lock:   []packager.LockEntry{pin("acme/base", "1.0.0")},
+			update: true,
+			want:   map[string]string{"acme/base": "1.1.0"},
+		},
+		{
+			name: "pin outside the range is replaced",
+			deps: map[string]string{"acme/base": "^2"},
+			lock: []packager.LockEntry{pin("acme/base", "1.0.0")},
+			want: map[string]string{"acme/base": "2.0.0"},
+		},
+		{
+			name: "pinned digest must match",
+			deps: map[string]string{"acme/base": "^1"},
+			lock: []packager.LockEntry{{Kind: packager.LockKindDependency, Source: "acme/base", Version: "1.0.0", Digest: "sha256:00"}},
+			err:  "digest mismatch for acme/base:1.0.0",
+		},
+		{
+			name: "yanked version required exactly",
+			deps: map[string]string{"acme/base": "=2.1.0"},
+			want: map[string]string{"acme/base": "2.1.0"},
+		},
+		{
+			name: "yanked version pinned",
+			deps: map[string]string{"acme/base": "^2"},
+			lock: []packager.LockEntry{pin("acme/base", "2.1.0")},
+			want: map[string]string{"acme/base": "2.1.0"},
+		},
+		{
+			name:   "yanked pin is dropped on update",
+			deps:   map[string]string{"acme/base": "^2"},
+			lock:   []packager.LockEntry{pin("acme/base", "2.1.0")},
+			update: true,
+			want:   map[string]string{"acme/base": "2.0.0"},
+		},
+		{
+			name: "yanked version outside an exact requirement",
+			deps: map[string]string{"acme/base": ">=2.1"},
+			err:  "no version of acme/base satisfies every requirement",
+		},
+		{
+			name: "dist-tag",
+			deps: map[string]string{"acme/base": "@beta"},
+			want: map[string]string{"acme/base": "3.0.0-beta.1"},
+		},
+		{
+			name: "unknown dist-tag",
+			deps: map[string]string{"acme/base": "@stable"},
+			err:  `promptbucket.yaml: dependency acme/base: acme/base has no dist-tag "stable"`,
+		},
+		{
+			name: "missing package",
+			deps: map[string]string{"acme/nope": "*"},
+			err:  "dependency acme/nope not found in the registry",
+		},
+		{
+			name: "invalid range",
+			deps: map[string]string{"acme/base": "banana"},
+			err:  "invalid version range",
+		},
+		{
+			name: "did not settle",
+			deps: map[string]string{"acme/x": "*", "acme/y": "*"},
+			err:  fmt.Sprintf("dependency resolution did not settle after %d rounds", maxRounds),
+		},
+	}
+
+	for _, tt := range tests {
+		r := &Resolver{
+			ClientFor: func(org, name string) *registry.Client { return client },
+			Update:    tt.update,
+		}
+		if tt.lock != nil {
+			r.Lock = &packager.Lock{LockVersion: packager.LockVersion, Packages: tt.lock}
+		}
+		resolved, err := r.Resolve(ctx, &packager.Manifest{Name: "root", Dependencies: tt.deps})
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: error = %v, want one containing %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		got := map[string]string{}
+		for _, res := range resolved {
+			got[res.FullName()] = res.Version
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: resolved %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResolveRequiredBy(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	publish(server, "acme/base", "1.0.0")
+	publish(server, "acme/app", "1.0.0", "acme/base", "~1.0")
+	server.SetDistTag("acme", "base", "stable", "1.0.0")
+	client := server.Client(t.TempDir())
+
+	r := &Resolver{ClientFor: func(org, name string) *registry.Client { return client }}
+	m := &packager.Manifest{Name: "root", Dependencies: map[string]string{"acme/app": "^1", "acme/base": "@stable"}}
+	resolved, err := r.Resolve(context.Background(), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resolved) != 2 || resolved[0].FullName() != "acme/app" || resolved[1].FullName() != "acme/base" {
+		t.Fatalf("Resolve() = %v, want acme/app and acme/base in order", resolved)
+	}
+
+	base := resolved[1]
+	sort.Strings(base.RequiredBy)
+	want := []string{"acme/app@1.0.0 (~1.0)", "promptbucket.yaml (@stable → 1.0.0)"}
+	if !reflect.DeepEqual(base.RequiredBy, want) {
+		t.Errorf("RequiredBy = %q, want %q", base.RequiredBy, want)
+	}
+	if base.Digest != cache.Digest(server.Manifest("acme", "base", "1.0.0")) || base.Manifest.Name != "base" {
+		t.Errorf("resolved %+v, want the published manifest", base)
+	}
+
+	entries := LockEntries(resolved)
+	if len(entries) != 2 || entries[1].Source != "acme/base" || entries[1].Name != "base" || entries[1].Version != "1.0.0" {
+		t.Errorf("LockEntries() = %+v", entries)
+	}
+}
+
+func TestSplitName(t *testing.T) {
+	for _, key := range []string{"acme", "acme/", "/demo", "acme/demo/extra", ""} {
+		if _, _, err := SplitName(key); err == nil {
+			t.Errorf("SplitName(%q) succeeded, want an error", key)
+		}
+	}
+	if org, name, err := SplitName("acme/demo"); err != nil || org != "acme" || name != "demo" {
+		t.Errorf("SplitName(acme/demo) = %q, %q, %v", org, name, err)
+	}
+}
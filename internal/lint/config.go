@@ -0,0 +1,86 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile is the lint configuration file looked up next to the manifest.
+const ConfigFile = ".promptbucket-lint.yaml"
+
+// Config selects rule severities and options.
+//
+//	rules:
+//	  prompt-length:
+//	    severity: error
+//	    options: { min: 50, max: 4000 }
+//	  tags-required: off
+type Config struct {
+	Rules map[string]RuleConfig `yaml:"rules"`
+}
+
+// RuleConfig overrides a single rule. Severity may be error, warning, info or
+// off; an empty severity keeps the rule's default.
+type RuleConfig struct {
+	Severity string    `yaml:"severity,omitempty"`
+	Options  yaml.Node `yaml:"options,omitempty"`
+}
+
+// UnmarshalYAML accepts either a mapping or a bare severity string, so
+// "tags-required: off" works as shorthand.
+func (rc *RuleConfig) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		rc.Severity = value.Value
+		return nil
+	}
+	type plain RuleConfig
+	return value.Decode((*plain)(rc))
+}
+
+// LoadConfig reads a lint configuration file and checks that every rule it
+// names exists.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lint config %s: %w", path, err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse lint config %s: %w", path, err)
+	}
+
+	for id := range cfg.Rules {
+		if lookupRule(id) == nil {
+			return nil, fmt.Errorf("lint config %s: unknown rule %q", path, id)
+		}
+	}
+	return &cfg, nil
+}
+
+// FindConfig returns the lint configuration that applies to a manifest: the
+// one in the manifest's directory, else the one in the working directory.
+// It returns an empty path if neither exists.
+func FindConfig(manifestPath string) string {
+	candidates := []string{
+		filepath.Join(filepath.Dir(manifestPath), ConfigFile),
+		ConfigFile,
+	}
+	for _, c := range candidates {
+		if _, err := os.Stat(c); err == nil {
+			return c
+		}
+	}
+	return ""
+}
+
+// rule returns the configuration for id, or a zero value.
+func (c *Config) rule(id string) RuleConfig {
+	if c == nil {
+		return RuleConfig{}
+	}
+	return c.Rules[id]
+}
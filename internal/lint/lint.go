@@ -0,0 +1,213 @@
+// Package lint runs quality rules over prompt manifests. Unlike schema
+// validation, lint rules flag manifests that are valid but likely to produce
+// poor or confusing prompts.
+package lint
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/promptbucket/cli/internal/diag"
+	"github.com/promptbucket/cli/internal/packager"
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a parsed manifest handed to each rule.
+type Document struct {
+	Path     string
+	Manifest *packager.Manifest
+	// Root is the top-level mapping node of the manifest.
+	Root *yaml.Node
+}
+
+// Node returns the value node at the given key path, or nil if any key is
+// missing. Integer-looking segments index into sequences.
+func (d *Document) Node(path ...string) *yaml.Node {
+	n := d.Root
+	for _, key := range path {
+		if n == nil {
+			return nil
+		}
+		n = child(n, key)
+	}
+	return n
+}
+
+// KeyNode returns the key node for a top-level or nested key, which is the
+// most useful position to report for "missing" or "whole value" issues.
+func (d *Document) KeyNode(path ...string) *yaml.Node {
+	if len(path) == 0 {
+		return d.Root
+	}
+	parent := d.Node(path[:len(path)-1]...)
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return nil
+	}
+	last := path[len(path)-1]
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == last {
+			return parent.Content[i]
+		}
+	}
+	return nil
+}
+
+func child(n *yaml.Node, key string) *yaml.Node {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == key {
+				return n.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		var idx int
+		if _, err := fmt.Sscanf(key, "%d", &idx); err == nil && idx >= 0 && idx < len(n.Content) {
+			return n.Content[idx]
+		}
+	}
+	return nil
+}
+
+// Options controls a lint run.
+type Options struct {
+	// Config overrides rule severities and options. It may be nil.
+	Config *Config
+}
+
+// File lints the manifest at path.
+func File(path string, opts Options) ([]diag.Diagnostic, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest file %s: %w", path, err)
+	}
+	return Source(path, data, opts)
+}
+
+// Source lints manifest data that was read from path.
+func Source(path string, data []byte, opts Options) ([]diag.Diagnostic, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid YAML syntax in %s: %w", path, err)
+	}
+	var m packager.Manifest
+	if err := root.Decode(&m); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest %s: %w", path, err)
+	}
+
+	doc := &Document{Path: path, Manifest: &m, Root: &root}
+	if len(root.Content) > 0 {
+		doc.Root = root.Content[0]
+	}
+
+	disabled := parseDisableComments(data)
+
+	var diags []diag.Diagnostic
+	for _, r := range registry {
+		rc := opts.Config.rule(r.ID())
+
+		sev := r.DefaultSeverity()
+		if rc.Severity != "" {
+			var err error
+			sev, err = diag.ParseSeverity(rc.Severity)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %w", r.ID(), err)
+			}
+			if sev == "" {
+				continue
+			}
+		}
+
+		issues, err := r.Check(doc, &rc.Options)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", r.ID(), err)
+		}
+		for _, is := range issues {
+			d := diag.Diagnostic{
+				File:     path,
+				Rule:     r.ID(),
+				Severity: sev,
+				Message:  is.Message,
+			}
+			if is.Node != nil {
+				d.Line, d.Column = is.Node.Line, is.Node.Column
+			}
+			if disabled.suppresses(d.Rule, d.Line) {
+				continue
+			}
+			diags = append(diags, d)
+		}
+	}
+
+	diag.Sort(diags)
+	return diags, nil
+}
+
+// Inline disable comments, modelled on the usual linter conventions:
+//
+//	# promptbucket-lint-disable [rule, ...]            whole file
+//	key: value # promptbucket-lint-disable-line [rule]  this line
+//	# promptbucket-lint-disable-next-line [rule]        the following line
+//
+// Omitting the rule list disables every rule.
+var disableRe = regexp.MustCompile(`#\s*promptbucket-lint-(disable-next-line|disable-line|disable)\b(.*)$`)
+
+// allRules marks a disable directive without a rule list.
+const allRules = "*"
+
+type disables struct {
+	file  map[string]bool
+	lines map[int]map[string]bool
+}
+
+func parseDisableComments(data []byte) disables {
+	d := disables{file: map[string]bool{}, lines: map[int]map[string]bool{}}
+	for i, line := range strings.Split(string(data), "\n") {
+		m := disableRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		rules := splitRuleList(m[2])
+		switch m[1] {
+		case "disable":
+			for _, r := range rules {
+				d.file[r] = true
+			}
+		case "disable-line":
+			d.add(i+1, rules)
+		case "disable-next-line":
+			d.add(i+2, rules)
+		}
+	}
+	return d
+}
+
+func (d disables) add(line int, rules []string) {
+	if d.lines[line] == nil {
+		d.lines[line] = map[string]bool{}
+	}
+	for _, r := range rules {
+		d.lines[line][r] = true
+	}
+}
+
+func (d disables) suppresses(rule string, line int) bool {
+	if d.file[allRules] || d.file[rule] {
+		return true
+	}
+	l := d.lines[line]
+	return l[allRules] || l[rule]
+}
+
+func splitRuleList(s string) []string {
+	var rules []string
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+		rules = append(rules, f)
+	}
+	if len(rules) == 0 {
+		return []string{allRules}
+	}
+	return rules
+}
@@ -0,0 +1,324 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/promptbucket/cli/internal/diag"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule is a single lint check.
+type Rule interface {
+	ID() string
+	Description() string
+	DefaultSeverity() diag.Severity
+	// Check inspects doc. opts holds the rule's "options" block from the lint
+	// config; its Kind is zero when none was given.
+	Check(doc *Document, opts *yaml.Node) ([]Issue, error)
+}
+
+// Issue is a rule finding before severity and suppression are applied.
+type Issue struct {
+	Node    *yaml.Node
+	Message string
+}
+
+// registry holds every built-in rule in reporting order.
+var registry = []Rule{
+	promptLengthRule{},
+	descriptionRequiredRule{},
+	tagsRequiredRule{},
+	personaContradictionRule{},
+	duplicateIdentityRule{},
+	modelHintRule{},
+}
+
+// Rules returns the built-in rules.
+func Rules() []Rule {
+	return append([]Rule(nil), registry...)
+}
+
+// RuleInfos returns rule metadata for report formats that embed it.
+func RuleInfos() []diag.RuleInfo {
+	infos := make([]diag.RuleInfo, 0, len(registry))
+	for _, r := range registry {
+		infos = append(infos, diag.RuleInfo{ID: r.ID(), Description: r.Description()})
+	}
+	return infos
+}
+
+func lookupRule(id string) Rule {
+	for _, r := range registry {
+		if r.ID() == id {
+			return r
+		}
+	}
+	return nil
+}
+
+// decodeOptions fills dst from a rule's options block, leaving defaults in
+// place when no options were configured.
+func decodeOptions(opts *yaml.Node, dst interface{}) error {
+	if opts == nil || opts.Kind == 0 {
+		return nil
+	}
+	if err := opts.Decode(dst); err != nil {
+		return fmt.Errorf("invalid options: %w", err)
+	}
+	return nil
+}
+
+// prompt-length
+
+type promptLengthRule struct{}
+
+type promptLengthOptions struct {
+	Min int `yaml:"min"`
+	Max int `yaml:"max"`
+}
+
+func (promptLengthRule) ID() string { return "prompt-length" }
+func (promptLengthRule) Description() string {
+	return "Prompt text should be neither trivially short nor excessively long"
+}
+func (promptLengthRule) DefaultSeverity() diag.Severity { return diag.SeverityWarning }
+
+func (promptLengthRule) Check(doc *Document, opts *yaml.Node) ([]Issue, error) {
+	o := promptLengthOptions{Min: 20, Max: 8000}
+	if err := decodeOptions(opts, &o); err != nil {
+		return nil, err
+	}
+
+	prompt := doc.Manifest.Prompt
+	if prompt == "" {
+		// A missing prompt is a schema error, not a lint finding.
+		return nil, nil
+	}
+	n := len([]rune(strings.TrimSpace(prompt)))
+	node := doc.Node("prompt")
+	switch {
+	case o.Min > 0 && n < o.Min:
+		return []Issue{{Node: node, Message: fmt.Sprintf("prompt is %d characters, shorter than the minimum of %d", n, o.Min)}}, nil
+	case o.Max > 0 && n > o.Max:
+		return []Issue{{Node: node, Message: fmt.Sprintf("prompt is %d characters, longer than the maximum of %d", n, o.Max)}}, nil
+	}
+	return nil, nil
+}
+
+// description-required
+
+type descriptionRequiredRule struct{}
+
+func (descriptionRequiredRule) ID() string { return "description-required" }
+func (descriptionRequiredRule) Description() string {
+	return "Packages should have a description so they can be found and understood"
+}
+func (descriptionRequiredRule) DefaultSeverity() diag.Severity { return diag.SeverityWarning }
+
+func (descriptionRequiredRule) Check(doc *Document, _ *yaml.Node) ([]Issue, error) {
+	if strings.TrimSpace(doc.Manifest.Description) != "" {
+		return nil, nil
+	}
+	node := doc.KeyNode("description")
+	if node == nil {
+		node = doc.KeyNode("name")
+	}
+	return []Issue{{Node: node, Message: "manifest has no description"}}, nil
+}
+
+// tags-required
+
+type tagsRequiredRule struct{}
+
+func (tagsRequiredRule) ID() string { return "tags-required" }
+func (tagsRequiredRule) Description() string {
+	return "Packages should have at least one tag for discovery"
+}
+func (tagsRequiredRule) DefaultSeverity() diag.Severity { return diag.SeverityInfo }
+
+func (tagsRequiredRule) Check(doc *Document, _ *yaml.Node) ([]Issue, error) {
+	if len(doc.Manifest.Tags) > 0 {
+		return nil, nil
+	}
+	node := doc.KeyNode("tags")
+	if node == nil {
+		node = doc.KeyNode("name")
+	}
+	return []Issue{{Node: node, Message: "manifest has no tags"}}, nil
+}
+
+// persona-contradiction
+
+type personaContradictionRule struct{}
+
+func (personaContradictionRule) ID() string { return "persona-contradiction" }
+func (personaContradictionRule) Description() string {
+	return "Persona constraints should not forbid what its preferences ask for"
+}
+func (personaContradictionRule) DefaultSeverity() diag.Severity { return diag.SeverityWarning }
+
+// negationRe matches the forbidding phrasings used in constraints, capturing
+// the thing being forbidden.
+var negationRe = regexp.MustCompile(`(?i)^\s*(?:never|no|avoid|don't|do not|without|not)\s+(?:use\s+|using\s+|provide\s+|providing\s+|include\s+|including\s+)?(.+?)\s*\.?$`)
+
+// negationWordRe finds a negation anywhere in a phrase, such as "plain
+// language without jargon".
+var negationWordRe = regexp.MustCompile(`(?i)\b(?:never|no|avoid|avoiding|don't|do not|without|not|nothing|none)\b`)
+
+// affirmationRe strips the leading verb phrases used in preferences.
+var affirmationRe = regexp.MustCompile(`(?i)^\s*(?:always\s+)?(?:(?:use|using|provide|providing|include|including|prefer|give|show|add)\b)?\s*(.+?)\s*\.?$`)
+
+func (personaContradictionRule) Check(doc *Document, _ *yaml.Node) ([]Issue, error) {
+	p := doc.Manifest.Persona
+	if p == nil || len(p.Constraints) == 0 || len(p.Preferences) == 0 {
+		return nil, nil
+	}
+
+	var issues []Issue
+	for ci, c := range p.Constraints {
+		m := negationRe.FindStringSubmatch(c)
+		if m == nil {
+			continue
+		}
+		forbidden := phraseTokens(m[1])
+		if forbidden == "" {
+			continue
+		}
+		for pi, pref := range p.Preferences {
+			// A negated preference agrees with the constraint
+			if negationWordRe.MatchString(pref) {
+				continue
+			}
+			if phraseTokens(affirmationRe.ReplaceAllString(pref, "$1")) != forbidden {
+				continue
+			}
+			issues = append(issues, Issue{
+				Node: doc.Node("persona", "preferences", fmt.Sprint(pi)),
+				Message: fmt.Sprintf("preference %q contradicts constraint %q (persona.constraints[%d])",
+					pref, c, ci),
+			})
+		}
+	}
+	return issues, nil
+}
+
+// phraseTokens returns the words of a phrase lowercased, without articles
+// or punctuation and in sorted order, so phrasing differences do not hide
+// a contradiction while unrelated phrases that share words do not match.
+func phraseTokens(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '-'
+	})
+	tokens := words[:0]
+	for _, w := range words {
+		switch w {
+		case "a", "an", "the", "any":
+		default:
+			tokens = append(tokens, w)
+		}
+	}
+	sort.Strings(tokens)
+	return strings.Join(tokens, " ")
+}
+
+// duplicate-identity
+
+type duplicateIdentityRule struct{}
+
+func (duplicateIdentityRule) ID() string { return "duplicate-identity" }
+func (duplicateIdentityRule) Description() string {
+	return `The prompt should not repeat the "You are ..." identity generated from the persona`
+}
+func (duplicateIdentityRule) DefaultSeverity() diag.Severity { return diag.SeverityWarning }
+
+var youAreRe = regexp.MustCompile(`(?i)\byou are\b`)
+
+func (duplicateIdentityRule) Check(doc *Document, _ *yaml.Node) ([]Issue, error) {
+	p := doc.Manifest.Persona
+	if p == nil || (p.Name == "" && p.Role == "") {
+		return nil, nil
+	}
+	loc := youAreRe.FindStringIndex(doc.Manifest.Prompt)
+	if loc == nil {
+		return nil, nil
+	}
+
+	return []Issue{{
+		Node:    promptLineNode(doc.Node("prompt"), doc.Manifest.Prompt, loc[0]),
+		Message: `prompt contains a "You are ..." statement but the persona already defines the identity`,
+	}}, nil
+}
+
+// promptLineNode returns a synthetic node pointing at the line within a block
+// scalar prompt that contains byte offset off, so editors land on the text
+// rather than the "prompt:" key.
+func promptLineNode(n *yaml.Node, prompt string, off int) *yaml.Node {
+	if n == nil {
+		return nil
+	}
+	if n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+		return n
+	}
+	line := n.Line + 1 + strings.Count(prompt[:off], "\n")
+	return &yaml.Node{Line: line, Column: n.Column}
+}
+
+// model-hint
+
+type modelHintRule struct{}
+
+type modelHintOptions struct {
+	// Known replaces the built-in list of model names.
+	Known []string `yaml:"known"`
+	// Additional extends the built-in list.
+	Additional []string `yaml:"additional"`
+}
+
+// knownModels are the model hints recognized out of the box.
+var knownModels = []string{
+	"gpt-4", "gpt-4-turbo", "gpt-4o", "gpt-4o-mini", "gpt-4.1", "gpt-4.1-mini",
+	"gpt-3.5-turbo", "o1", "o1-mini", "o3", "o3-mini", "o4-mini",
+	"claude-3", "claude-3-opus", "claude-3-sonnet", "claude-3-haiku",
+	"claude-3-5-sonnet", "claude-3-5-haiku", "claude-3-7-sonnet",
+	"claude-sonnet-4", "claude-opus-4",
+	"gemini-1.5-pro", "gemini-1.5-flash", "gemini-2.0-flash", "gemini-2.5-pro", "gemini-2.5-flash",
+	"llama-3", "llama-3.1", "llama-3.2", "llama-3.3",
+	"mistral-large", "mistral-small", "mixtral-8x7b",
+}
+
+func (modelHintRule) ID() string { return "model-hint" }
+func (modelHintRule) Description() string {
+	return "model_hint should name a known model"
+}
+func (modelHintRule) DefaultSeverity() diag.Severity { return diag.SeverityWarning }
+
+func (modelHintRule) Check(doc *Document, opts *yaml.Node) ([]Issue, error) {
+	var o modelHintOptions
+	if err := decodeOptions(opts, &o); err != nil {
+		return nil, err
+	}
+
+	hint := doc.Manifest.ModelHint
+	if hint == "" {
+		return nil, nil
+	}
+
+	known := knownModels
+	if len(o.Known) > 0 {
+		known = o.Known
+	}
+	known = append(append([]string(nil), known...), o.Additional...)
+	for _, k := range known {
+		if strings.EqualFold(k, hint) {
+			return nil, nil
+		}
+	}
+	return []Issue{{
+		Node:    doc.Node("model_hint"),
+		Message: fmt.Sprintf("model_hint %q is not a known model", hint),
+	}}, nil
+}
@@ -0,0 +1,76 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/promptbucket/cli/internal/diag"
+)
+
+func TestPersonaContradiction(t *testing.T) {
+	tests := []struct {
+		name        string
+		constraints []string
+		preferences []string
+		want        int
+	}{
+		{"same thing forbidden and wanted", []string{"Never use emojis"}, []string{"Use emojis"}, 1},
+		{"word order and articles", []string{"Do not include code examples."}, []string{"Always include the examples code"}, 1},
+		{"negated preference agrees", []string{"No jargon"}, []string{"Avoid jargon"}, 0},
+		{"negation inside preference", []string{"No jargon"}, []string{"plain language without jargon"}, 0},
+		{"shared word is not a match", []string{"No jargon"}, []string{"Explain jargon-free terms"}, 0},
+		{"longer preference is not a match", []string{"Avoid long answers"}, []string{"Long answers with detailed examples"}, 0},
+		{"affirmative constraint is ignored", []string{"Be concise"}, []string{"Be concise"}, 0},
+		{"each contradicting preference", []string{"No emojis"}, []string{"Use emojis", "Be brief", "emojis"}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			b.WriteString("name: demo\nversion: 1.0.0\nprompt: Hello\npersona:\n  constraints:\n")
+			for _, c := range tt.constraints {
+				b.WriteString("    - " + c + "\n")
+			}
+			b.WriteString("  preferences:\n")
+			for _, p := range tt.preferences {
+				b.WriteString("    - " + p + "\n")
+			}
+
+			diags, err := Source("promptbucket.yaml", []byte(b.String()), Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []diag.Diagnostic
+			for _, d := range diags {
+				if d.Rule == "persona-contradiction" {
+					got = append(got, d)
+				}
+			}
+			if len(got) != tt.want {
+				t.Fatalf("got %d contradiction(s), want %d: %v", len(got), tt.want, got)
+			}
+			for _, d := range got {
+				if d.Severity != diag.SeverityWarning {
+					t.Errorf("severity = %s, want warning", d.Severity)
+				}
+			}
+		})
+	}
+}
+
+func TestPhraseTokens(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Code examples", "code examples"},
+		{"  the   EXAMPLES, code. ", "code examples"},
+		{"an emoji", "emoji"},
+		{"jargon-free", "jargon-free"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := phraseTokens(tt.in); got != tt.want {
+			t.Errorf("phraseTokens(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package packager
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/promptbucket/cli/internal/semver"
+	"github.com/promptbucket/cli/internal/version"
+)
+
+// CheckCompatibility refuses manifests this CLI cannot interpret correctly:
+// those written in a newer schema_version, and those whose
+// engines.promptbucket requires a newer CLI.
+func CheckCompatibility(m *Manifest) error {
+	if err := CheckSchemaVersion(m); err != nil {
+		return err
+	}
+	return CheckEngines(m)
+}
+
+// CheckSchemaVersion fails if the manifest uses a schema newer than
+// SchemaVersion. Manifests without a schema_version predate versioning and
+// are accepted; 'promptbucket migrate' upgrades them.
+func CheckSchemaVersion(m *Manifest) error {
+	if m.SchemaVersion > SchemaVersion {
+		return fmt.Errorf("manifest uses schema_version %d but this CLI only understands up to %d; upgrade promptbucket", m.SchemaVersion, SchemaVersion)
+	}
+	return nil
+}
+
+// CheckEngines fails if the running CLI is older than engines.promptbucket.
+// Development builds have no version and satisfy every requirement.
+func CheckEngines(m *Manifest) error {
+	if m.Engines == nil || m.Engines.Promptbucket == "" {
+		return nil
+	}
+	min, err := ParseEngineRequirement(m.Engines.Promptbucket)
+	if err != nil {
+		return err
+	}
+	current, err := semver.Parse(strings.TrimPrefix(version.Version, "v"))
+	if err != nil {
+		return nil
+	}
+	if current.Less(min) {
+		return fmt.Errorf("manifest requires promptbucket %s or newer, but this is %s; upgrade promptbucket", min, current)
+	}
+	return nil
+}
+
+// ParseEngineRequirement parses an engines.promptbucket minimum version,
+// written as "0.4.0" or ">=0.4.0".
+func ParseEngineRequirement(req string) (semver.Version, error) {
+	v, err := semver.Parse(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(req), ">=")))
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("invalid engines.promptbucket %q: %w", req, err)
+	}
+	return v, nil
+}
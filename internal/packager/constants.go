@@ -3,4 +3,7 @@ package packager
 const (
     ManifestFile = "promptbucket.yaml"
     MagicHeader  = "PBKT\x00"
+
+    // SchemaVersion is the newest manifest schema_version this CLI understands
+    SchemaVersion = 1
 )
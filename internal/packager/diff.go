@@ -0,0 +1,216 @@
+package packager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestChange is one difference between two versions of a manifest.
+type ManifestChange struct {
+	// Field names what changed, such as "version", "tags" or
+	// "variables[topic].example". List entries with a name are matched by it.
+	Field string
+	// Old and New are the values before and after, rendered as YAML. Old is
+	// empty for additions and New for removals.
+	Old string
+	New string
+}
+
+// Added reports whether the change adds a field or list item.
+func (c ManifestChange) Added() bool { return c.Old == "" }
+
+// Removed reports whether the change removes a field or list item.
+func (c ManifestChange) Removed() bool { return c.New == "" }
+
+// DiffManifests compares two manifests field by field, ignoring formatting,
+// comments and key order. Changes are returned in field order.
+func DiffManifests(old, new []byte) ([]ManifestChange, error) {
+	var a, b interface{}
+	if err := yaml.Unmarshal(old, &a); err != nil {
+		return nil, fmt.Errorf("failed to parse old manifest: %w", err)
+	}
+	if err := yaml.Unmarshal(new, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse new manifest: %w", err)
+	}
+	var changes []ManifestChange
+	diffValues("", a, b, &changes)
+	return changes, nil
+}
+
+func diffValues(field string, a, b interface{}, changes *[]ManifestChange) {
+	switch {
+	case a == nil && b == nil:
+		return
+	case a == nil:
+		*changes = append(*changes, ManifestChange{Field: field, New: renderValue(b)})
+		return
+	case b == nil:
+		*changes = append(*changes, ManifestChange{Field: field, Old: renderValue(a)})
+		return
+	}
+
+	am, aIsMap := a.(map[string]interface{})
+	bm, bIsMap := b.(map[string]interface{})
+	if aIsMap && bIsMap {
+		keys := map[string]bool{}
+		for k := range am {
+			keys[k] = true
+		}
+		for k := range bm {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			diffValues(joinField(field, k), am[k], bm[k], changes)
+		}
+		return
+	}
+
+	al, aIsList := a.([]interface{})
+	bl, bIsList := b.([]interface{})
+	if aIsList && bIsList {
+		diffLists(field, al, bl, changes)
+		return
+	}
+
+	if oldValue, newValue := renderValue(a), renderValue(b); oldValue != newValue {
+		*changes = append(*changes, ManifestChange{Field: field, Old: oldValue, New: newValue})
+	}
+}
+
+// diffLists matches entries with a "name" by it, such as variables, and
+// other entries by value, such as tags.
+func diffLists(field string, a, b []interface{}, changes *[]ManifestChange) {
+	aNamed, aOK := byName(a)
+	bNamed, bOK := byName(b)
+	if aOK && bOK {
+		var names []string
+		seen := map[string]bool{}
+		for _, list := range [][]interface{}{a, b} {
+			for _, item := range list {
+				name := fmt.Sprint(item.(map[string]interface{})["name"])
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+		}
+		for _, name := range names {
+			diffValues(fmt.Sprintf("%s[%s]", field, name), aNamed[name], bNamed[name], changes)
+		}
+		return
+	}
+
+	count := func(list []interface{}) map[string]int {
+		counts := map[string]int{}
+		for _, item := range list {
+			counts[renderValue(item)]++
+		}
+		return counts
+	}
+	aCounts, bCounts := count(a), count(b)
+	for _, item := range a {
+		if v := renderValue(item); bCounts[v] > 0 {
+			bCounts[v]--
+		} else {
+			*changes = append(*changes, ManifestChange{Field: field, Old: v})
+		}
+	}
+	for _, item := range b {
+		if v := renderValue(item); aCounts[v] > 0 {
+			aCounts[v]--
+		} else {
+			*changes = append(*changes, ManifestChange{Field: field, New: v})
+		}
+	}
+}
+
+// byName indexes list entries by their "name", if every entry has a
+// distinct one.
+func byName(list []interface{}) (map[string]interface{}, bool) {
+	named := map[string]interface{}{}
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok || m["name"] == nil {
+			return nil, false
+		}
+		name := fmt.Sprint(m["name"])
+		if _, dup := named[name]; dup {
+			return nil, false
+		}
+		named[name] = item
+	}
+	return named, len(named) > 0
+}
+
+func joinField(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// renderValue renders a value as compact YAML; strings are left unquoted.
+func renderValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		out, err := yaml.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	return fmt.Sprint(v)
+}
+
+// LineDiff compares two texts line by line and returns the lines of b
+// prefixed with "  " if unchanged or "+ " if added, and removed lines of a
+// prefixed with "- ", in order.
+func LineDiff(a, b string) []string {
+	x := strings.Split(a, "\n")
+	y := strings.Split(b, "\n")
+
+	// Longest common subsequence table, built from the end
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			lines = append(lines, "  "+x[i])
+			i++
+			j++
+		case j < len(y) && (i == len(x) || lcs[i][j+1] > lcs[i+1][j]):
+			lines = append(lines, "+ "+y[j])
+			j++
+		default:
+			lines = append(lines, "- "+x[i])
+			i++
+		}
+	}
+	return lines
+}
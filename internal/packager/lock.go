@@ -0,0 +1,193 @@
+package packager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/promptbucket/cli/internal/atomicfile"
+	"github.com/promptbucket/cli/internal/cache"
+	"gopkg.in/yaml.v3"
+)
+
+// LockFile pins every remote manifest a project resolves, next to
+// ManifestFile.
+const LockFile = "promptbucket.lock"
+
+// LockVersion is the lockfile format this CLI writes.
+const LockVersion = 1
+
+// Lock kinds.
+const (
+	LockKindParent     = "parent"
+	LockKindDependency = "dependency"
+)
+
+// Lock records the exact content of each remote manifest a project uses, so
+// builds are reproducible even if the URL's content changes.
+type Lock struct {
+	LockVersion int         `yaml:"lock_version"`
+	Packages    []LockEntry `yaml:"packages"`
+}
+
+// LockEntry pins one resolved manifest.
+type LockEntry struct {
+	// Kind is how the manifest is used: "parent" for a from: URL, or
+	// "dependency" for a registry package listed in dependencies.
+	Kind string `yaml:"kind"`
+	// Source is the from: reference as written, or "org/name" for a
+	// dependency.
+	Source  string `yaml:"source"`
+	Name    string `yaml:"name,omitempty"`
+	Version string `yaml:"version,omitempty"`
+	// Digest is the sha256 of the manifest bytes.
+	Digest string `yaml:"digest"`
+}
+
+// ReadLock reads a lockfile, returning nil if it does not exist.
+func ReadLock(path string) (*Lock, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var l Lock
+	if err := yaml.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if l.LockVersion > LockVersion {
+		return nil, fmt.Errorf("%s uses lock_version %d but this CLI supports up to %d; upgrade promptbucket", path, l.LockVersion, LockVersion)
+	}
+	return &l, nil
+}
+
+// WriteLock writes a lockfile with entries in a stable order.
+func WriteLock(path string, l *Lock) error {
+	l.LockVersion = LockVersion
+	sort.Slice(l.Packages, func(i, j int) bool {
+		a, b := l.Packages[i], l.Packages[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Source < b.Source
+	})
+	var buf bytes.Buffer
+	buf.WriteString("# Generated by 'promptbucket lock'. Do not edit; run 'promptbucket lock --update' to refresh.\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(l); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	if err := atomicfile.Write(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Find returns the entry for a source of the given kind.
+func (l *Lock) Find(kind, source string) (LockEntry, bool) {
+	if l == nil {
+		return LockEntry{}, false
+	}
+	for _, e := range l.Packages {
+		if e.Kind == kind && e.Source == source {
+			return e, true
+		}
+	}
+	return LockEntry{}, false
+}
+
+// Load returns the locked content of a source. Content is taken from the
+// cache by digest when possible, so locked builds work offline; otherwise it
+// is downloaded and must match the recorded digest.
+func (l *Lock) Load(ctx context.Context, kind, source string) ([]byte, error) {
+	e, ok := l.Find(kind, source)
+	if !ok {
+		return nil, fmt.Errorf("%s is not in %s; run 'promptbucket lock' to add it", source, LockFile)
+	}
+	c := cache.Default()
+	if data, ok := c.Blob(e.Digest); ok {
+		return data, nil
+	}
+	data, err := readManifestSource(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	if got := cache.Digest(data); got != e.Digest {
+		return nil, fmt.Errorf("digest mismatch for %s: %s has %s but the source now has %s; if the change is expected, run 'promptbucket lock --update'", source, LockFile, e.Digest, got)
+	}
+	return data, nil
+}
+
+// FlattenManifestLocked resolves the inheritance chain like FlattenManifest,
+// but takes remote parents from the lock and fails if they have changed.
+// Local parents are part of the project and are read as they are.
+func FlattenManifestLocked(ctx context.Context, m *Manifest, l *Lock) (*Manifest, error) {
+	return flattenManifest(m, func(source string) (*Manifest, error) {
+		if !isRemote(source) {
+			return LoadManifestFromPath(ctx, source)
+		}
+		data, err := l.Load(ctx, LockKindParent, source)
+		if err != nil {
+			return nil, err
+		}
+		return parseManifestSource(source, data)
+	})
+}
+
+// FlattenProjectManifest flattens a manifest in the current directory,
+// honoring LockFile if there is one.
+func FlattenProjectManifest(ctx context.Context, m *Manifest) (*Manifest, error) {
+	l, err := ReadLock(LockFile)
+	if err != nil {
+		return nil, err
+	}
+	if l == nil {
+		return FlattenManifest(ctx, m)
+	}
+	return FlattenManifestLocked(ctx, m, l)
+}
+
+// ResolveLock resolves every remote manifest m uses and returns a lock for
+// them. Sources already pinned in previous keep their pinned content unless
+// update is set, in which case everything is fetched afresh.
+func ResolveLock(ctx context.Context, m *Manifest, previous *Lock, update bool) (*Lock, error) {
+	l := &Lock{LockVersion: LockVersion}
+	_, err := flattenManifest(m, func(source string) (*Manifest, error) {
+		if !isRemote(source) {
+			return LoadManifestFromPath(ctx, source)
+		}
+
+		var data []byte
+		var err error
+		if _, pinned := previous.Find(LockKindParent, source); pinned && !update {
+			data, err = previous.Load(ctx, LockKindParent, source)
+		} else {
+			data, err = readManifestSource(ctx, source)
+		}
+		if err != nil {
+			return nil, err
+		}
+		parent, err := parseManifestSource(source, data)
+		if err != nil {
+			return nil, err
+		}
+		l.Packages = append(l.Packages, LockEntry{
+			Kind:    LockKindParent,
+			Source:  source,
+			Name:    parent.Name,
+			Version: parent.Version,
+			Digest:  cache.Digest(data),
+		})
+		return parent, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
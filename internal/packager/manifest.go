@@ -36,7 +36,15 @@ type Persona struct {
     OutputFormat string   `yaml:"output_format,omitempty"` // e.g., "markdown", "structured", "conversational"
 }
 
+// Engines declares the tool versions a manifest needs.
+type Engines struct {
+    // Promptbucket is the minimum CLI version, e.g. ">=0.4.0"
+    Promptbucket string `yaml:"promptbucket,omitempty"`
+}
+
 type Manifest struct {
+    SchemaVersion int      `yaml:"schema_version,omitempty"`
+    Engines     *Engines   `yaml:"engines,omitempty"`
     Name        string     `yaml:"name"`
     Version     string     `yaml:"version"`
     Licence     string     `yaml:"licence"`
@@ -49,5 +57,11 @@ type Manifest struct {
     Persona     *Persona   `yaml:"persona,omitempty"`
     Variables   []Variable `yaml:"variables,omitempty"`
     Prompt      string     `yaml:"prompt"`
+    // MaxTokensBudget caps the rendered persona and prompt size, counted
+    // with the tokenizer for ModelHint.
+    MaxTokensBudget int    `yaml:"max_tokens_budget,omitempty"`
+    // Dependencies maps "org/name" to the semver range of a package used
+    // alongside this one; 'promptbucket install' resolves them.
+    Dependencies map[string]string `yaml:"dependencies,omitempty"`
     Digest      string     `yaml:"digest,omitempty"`
 }
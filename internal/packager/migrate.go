@@ -0,0 +1,167 @@
+package packager
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// migration upgrades a manifest document from one schema version to the
+// next. Migrations edit the YAML node tree rather than the Manifest struct so
+// that comments, key order and scalar styles survive.
+type migration struct {
+	from        int
+	description string
+	// apply is nil for migrations that only declare the new schema_version;
+	// those edit the file text in place instead of re-encoding it.
+	apply func(root *yaml.Node) error
+}
+
+// migrations must cover every schema version from 0 up to SchemaVersion-1.
+var migrations = []migration{
+	{
+		// Version 1 is the unversioned format with schema_version declared.
+		from:        0,
+		description: "declare schema_version 1",
+	},
+}
+
+// Migrate rewrites manifest data to the current SchemaVersion. It returns the
+// rewritten data and a description of each step applied; when the manifest is
+// already current, data is returned unchanged with no steps.
+func Migrate(data []byte) ([]byte, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("manifest is not a YAML mapping")
+	}
+	root := doc.Content[0]
+
+	current := 0
+	if n := mappingValue(root, "schema_version"); n != nil {
+		v, err := strconv.Atoi(n.Value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid schema_version %q", n.Value)
+		}
+		current = v
+	}
+	if current > SchemaVersion {
+		return nil, nil, fmt.Errorf("manifest uses schema_version %d but this CLI only understands up to %d; upgrade promptbucket", current, SchemaVersion)
+	}
+
+	// Find the schema_version node before migrations move things around
+	versionNode := mappingValue(root, "schema_version")
+	firstKey := root.Content[0]
+
+	var steps []string
+	reencode := false
+	for _, mig := range migrations {
+		if mig.from != current {
+			continue
+		}
+		if mig.apply != nil {
+			if err := mig.apply(root); err != nil {
+				return nil, nil, fmt.Errorf("migration from schema_version %d failed: %w", mig.from, err)
+			}
+			reencode = true
+		}
+		current++
+		setSchemaVersion(root, current)
+		steps = append(steps, mig.description)
+	}
+	if current != SchemaVersion {
+		return nil, nil, fmt.Errorf("no migration from schema_version %d", current)
+	}
+	if len(steps) == 0 {
+		return data, nil, nil
+	}
+	if !reencode {
+		return spliceSchemaVersion(data, versionNode, firstKey, current), steps, nil
+	}
+
+	// The encoder keeps each node's style, except where YAML cannot express
+	// it, such as a block scalar with trailing spaces
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	return buf.Bytes(), steps, nil
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// spliceSchemaVersion declares schema_version v in the file text, replacing
+// the value of versionNode if the manifest has one and otherwise inserting
+// the key on the line of firstKey, so the rest of the file is untouched.
+func spliceSchemaVersion(data []byte, versionNode, firstKey *yaml.Node, v int) []byte {
+	value := strconv.Itoa(v)
+	if versionNode != nil {
+		start := offsetOf(data, versionNode.Line, versionNode.Column)
+		end := start + len(versionNode.Value)
+		if versionNode.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+			end += 2
+		}
+		return append(append(append([]byte{}, data[:start]...), value...), data[end:]...)
+	}
+
+	newline := "\n"
+	if bytes.Contains(data, []byte("\r\n")) {
+		newline = "\r\n"
+	}
+	start := offsetOf(data, firstKey.Line, 1)
+	line := "schema_version: " + value + newline
+	return append(append(append([]byte{}, data[:start]...), line...), data[start:]...)
+}
+
+// offsetOf returns the byte offset of a 1-based line and column, where
+// columns count characters.
+func offsetOf(data []byte, line, column int) int {
+	off := 0
+	for l := 1; l < line; l++ {
+		i := bytes.IndexByte(data[off:], '\n')
+		if i < 0 {
+			return len(data)
+		}
+		off += i + 1
+	}
+	for c := 1; c < column && off < len(data); c++ {
+		_, size := utf8.DecodeRune(data[off:])
+		off += size
+	}
+	return off
+}
+
+// setSchemaVersion updates schema_version, adding it as the first key if
+// the manifest does not declare one.
+func setSchemaVersion(root *yaml.Node, v int) {
+	value := strconv.Itoa(v)
+	if n := mappingValue(root, "schema_version"); n != nil {
+		n.Value = value
+		return
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "schema_version"}
+	val := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: value}
+	if len(root.Content) > 0 {
+		// Keep a leading file comment at the top of the file
+		key.HeadComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+	}
+	root.Content = append([]*yaml.Node{key, val}, root.Content...)
+}
@@ -0,0 +1,116 @@
+package packager
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "block scalar with trailing spaces",
+			in:   "name: demo\nversion: 1.0.0\nprompt: |-\n  Design the website like apple \n  \n  Keep it simple.",
+			want: "schema_version: 1\nname: demo\nversion: 1.0.0\nprompt: |-\n  Design the website like apple \n  \n  Keep it simple.",
+		},
+		{
+			name: "folded scalar and comments",
+			in:   "# Team prompt\nname: demo # the package\nversion: 1.0.0\nprompt: >\n  One line\n  folded.\n",
+			want: "# Team prompt\nschema_version: 1\nname: demo # the package\nversion: 1.0.0\nprompt: >\n  One line\n  folded.\n",
+		},
+		{
+			name: "trailing whitespace after keys",
+			in:   "name: demo\ntags: \n  - a\n",
+			want: "schema_version: 1\nname: demo\ntags: \n  - a\n",
+		},
+		{
+			name: "existing schema_version",
+			in:   "name: demo\nschema_version: 0 # old\nprompt: 'quoted'\n",
+			want: "name: demo\nschema_version: 1 # old\nprompt: 'quoted'\n",
+		},
+		{
+			name: "quoted schema_version",
+			in:   "schema_version: \"0\"\nname: demo\n",
+			want: "schema_version: 1\nname: demo\n",
+		},
+		{
+			name: "windows line endings",
+			in:   "name: demo\r\nversion: 1.0.0\r\n",
+			want: "schema_version: 1\r\nname: demo\r\nversion: 1.0.0\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, steps, err := Migrate([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(steps) != 1 {
+				t.Errorf("steps = %v, want one", steps)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Migrate() =\n%q\nwant\n%q", got, tt.want)
+			}
+
+			// Migrating again changes nothing
+			again, steps, err := Migrate(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(steps) != 0 || string(again) != string(got) {
+				t.Errorf("second Migrate() = %q, %v; want it unchanged", again, steps)
+			}
+		})
+	}
+}
+
+func TestMigrateErrors(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"newer schema", "schema_version: 99\nname: demo\n", "upgrade promptbucket"},
+		{"invalid schema", "schema_version: one\nname: demo\n", "invalid schema_version"},
+		{"not a mapping", "- a\n- b\n", "not a YAML mapping"},
+		{"invalid yaml", "name: [\n", "failed to parse manifest"},
+	}
+	for _, tt := range tests {
+		if _, _, err := Migrate([]byte(tt.in)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want one containing %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+// TestMigrateReencodeKeepsStyles checks migrations that edit the node tree,
+// which re-encode the document.
+func TestMigrateReencodeKeepsStyles(t *testing.T) {
+	saved := migrations
+	defer func() { migrations = saved }()
+	migrations = []migration{{
+		from:        0,
+		description: "rename title to description",
+		apply: func(root *yaml.Node) error {
+			for i := 0; i < len(root.Content); i += 2 {
+				if root.Content[i].Value == "title" {
+					root.Content[i].Value = "description"
+				}
+			}
+			return nil
+		},
+	}}
+
+	in := "# Team prompt\nname: demo\ntitle: 'A demo'\nprompt: |\n  Line one\n  Line two\n"
+	want := "# Team prompt\nschema_version: 1\nname: demo\ndescription: 'A demo'\nprompt: |\n  Line one\n  Line two\n"
+	got, _, err := Migrate([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("Migrate() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -4,15 +4,18 @@ import (
     "archive/tar"
     "bytes"
     "compress/gzip"
+    "context"
     "crypto/sha256"
     "encoding/hex"
     "fmt"
     "io"
-    "net/http"
     "os"
     "regexp"
     "strings"
 
+    "github.com/promptbucket/cli/internal/atomicfile"
+    "github.com/promptbucket/cli/internal/cache"
+    "github.com/promptbucket/cli/internal/tokens"
     "gopkg.in/yaml.v3"
 )
 
@@ -22,8 +25,15 @@ type Artifact struct {
     Digest string
 }
 
+// BundleFiles lists the files Build packs into a .promptbucket archive,
+// relative to the package directory, for the secret scan. Build only packs
+// the manifest; keep the two in step if that changes.
+func BundleFiles() []string {
+    return []string{ManifestFile}
+}
+
 // Build reads promptbucket.yaml and produces a .promptbucket package in the current directory.
-func Build() (Artifact, error) {
+func Build(ctx context.Context) (Artifact, error) {
     var art Artifact
     data, err := os.ReadFile(ManifestFile)
     if err != nil {
@@ -37,6 +47,25 @@ func Build() (Artifact, error) {
     if m.Name == "" || m.Version == "" || m.Licence == "" || m.Prompt == "" {
         return art, fmt.Errorf("manifest missing required fields")
     }
+    if err := CheckCompatibility(&m); err != nil {
+        return art, err
+    }
+
+    // Verify locked parents, and enforce the token budget against the prompt
+    // rendered with example values
+    lock, err := ReadLock(LockFile)
+    if err != nil {
+        return art, err
+    }
+    if m.MaxTokensBudget > 0 || lock != nil {
+        flattened, err := FlattenProjectManifest(ctx, &m)
+        if err != nil {
+            return art, err
+        }
+        if err := CheckTokenBudget(flattened, RenderPrompt(flattened, ExampleVariables(flattened))); err != nil {
+            return art, err
+        }
+    }
 
     // tar
     var tarBuf bytes.Buffer
@@ -67,7 +96,7 @@ func Build() (Artifact, error) {
     digest := "sha256:" + hex.EncodeToString(sum[:])
 
     out := fmt.Sprintf("%s-%s.promptbucket", m.Name, m.Version)
-    if err := os.WriteFile(out, payload, 0644); err != nil {
+    if err := atomicfile.Write(out, payload, 0644); err != nil {
         return art, err
     }
     info, err := os.Stat(out)
@@ -81,6 +110,38 @@ func Build() (Artifact, error) {
     return art, nil
 }
 
+// ReadArchiveManifest extracts the manifest from a .promptbucket archive.
+func ReadArchiveManifest(path string) ([]byte, error) {
+    payload, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    if !bytes.HasPrefix(payload, []byte(MagicHeader)) {
+        return nil, fmt.Errorf("%s is not a .promptbucket archive", path)
+    }
+    
+    gr, err := gzip.NewReader(bytes.NewReader(payload[len(MagicHeader):]))
+    if err != nil {
+        return nil, fmt.Errorf("failed to decompress %s: %w", path, err)
+    }
+    defer gr.Close()
+    
+    tr := tar.NewReader(gr)
+    for {
+        hdr, err := tr.Next()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, fmt.Errorf("failed to read %s: %w", path, err)
+        }
+        if hdr.Name == ManifestFile {
+            return io.ReadAll(tr)
+        }
+    }
+    return nil, fmt.Errorf("%s does not contain %s", path, ManifestFile)
+}
+
 // ValidateVariables checks if all required variables are provided
 func ValidateVariables(m *Manifest, vars map[string]string) error {
     for _, v := range m.Variables {
@@ -116,48 +177,65 @@ func ParseVarFlags(varFlags []string) (map[string]string, error) {
     return vars, nil
 }
 
-// LoadManifestFromPath loads a manifest from a local file or URL
-func LoadManifestFromPath(path string) (*Manifest, error) {
-    var data []byte
-    var err error
-    
-    if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
-        resp, err := http.Get(path)
-        if err != nil {
-            return nil, fmt.Errorf("failed to fetch %s: %w", path, err)
-        }
-        defer resp.Body.Close()
-        
-        data, err = io.ReadAll(resp.Body)
-        if err != nil {
-            return nil, fmt.Errorf("failed to read response from %s: %w", path, err)
-        }
-    } else {
-        data, err = os.ReadFile(path)
-        if err != nil {
-            return nil, fmt.Errorf("failed to read file %s: %w", path, err)
-        }
+// LoadManifestFromPath loads a manifest from a local file, URL or registry
+// reference such as org/name:1.0.0 or org/name:@stable
+func LoadManifestFromPath(ctx context.Context, path string) (*Manifest, error) {
+    data, err := readManifestSource(ctx, path)
+    if err != nil {
+        return nil, err
     }
-    
+    return parseManifestSource(path, data)
+}
+
+// readManifestSource reads a manifest file, or downloads a URL or registry
+// reference through the cache
+func readManifestSource(ctx context.Context, path string) ([]byte, error) {
+    if isURL(path) {
+        return cache.Default().Fetch(ctx, path)
+    }
+    if ref, ok := parseRegistryRef(path); ok {
+        return readRegistryManifest(ctx, ref)
+    }
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read file %s: %w", path, err)
+    }
+    return data, nil
+}
+
+func parseManifestSource(path string, data []byte) (*Manifest, error) {
     var m Manifest
     if err := yaml.Unmarshal(data, &m); err != nil {
         return nil, fmt.Errorf("failed to parse YAML from %s: %w", path, err)
     }
-    
     return &m, nil
 }
 
+func isURL(path string) bool {
+    return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
 // FlattenManifest resolves inheritance chain up to 2 levels
-func FlattenManifest(m *Manifest) (*Manifest, error) {
+func FlattenManifest(ctx context.Context, m *Manifest) (*Manifest, error) {
+    return flattenManifest(m, func(source string) (*Manifest, error) {
+        return LoadManifestFromPath(ctx, source)
+    })
+}
+
+// flattenManifest resolves the inheritance chain, loading parents with load
+func flattenManifest(m *Manifest, load func(source string) (*Manifest, error)) (*Manifest, error) {
     result := *m
     depth := 0
     current := &result
     
     for current.From != "" && depth < 2 {
-        parent, err := LoadManifestFromPath(current.From)
+        parent, err := load(current.From)
         if err != nil {
             return nil, fmt.Errorf("failed to load parent manifest from %s: %w", current.From, err)
         }
+        if err := CheckCompatibility(parent); err != nil {
+            return nil, fmt.Errorf("parent manifest %s: %w", current.From, err)
+        }
         
         // Merge parent into current (child overrides parent)
         merged := mergeManifests(parent, current)
@@ -176,6 +254,10 @@ func FlattenManifest(m *Manifest) (*Manifest, error) {
 func mergeManifests(parent, child *Manifest) *Manifest {
     result := *parent
     
+    // The child's format and engine requirements describe the merged result
+    result.SchemaVersion = child.SchemaVersion
+    result.Engines = child.Engines
+    
     // Child overrides parent for all fields except variables which are merged
     if child.Name != "" {
         result.Name = child.Name
@@ -204,6 +286,21 @@ func mergeManifests(parent, child *Manifest) *Manifest {
     if child.Prompt != "" {
         result.Prompt = child.Prompt
     }
+    if child.MaxTokensBudget != 0 {
+        result.MaxTokensBudget = child.MaxTokensBudget
+    }
+    
+    // Merge dependencies (child ranges override parent ranges)
+    if len(child.Dependencies) > 0 {
+        deps := make(map[string]string, len(parent.Dependencies)+len(child.Dependencies))
+        for name, r := range parent.Dependencies {
+            deps[name] = r
+        }
+        for name, r := range child.Dependencies {
+            deps[name] = r
+        }
+        result.Dependencies = deps
+    }
     
     // Merge persona (child completely overrides parent persona)
     if child.Persona != nil {
@@ -231,58 +328,79 @@ func mergeManifests(parent, child *Manifest) *Manifest {
 }
 
 // BuildWithVariables builds a prompt with variable substitution and generates final_prompt.md
-func BuildWithVariables(varFlags []string) error {
+func BuildWithVariables(ctx context.Context, varFlags []string) error {
+    _, err := buildWithVariables(ctx, ManifestFile, FlattenProjectManifest, varFlags)
+    return err
+}
+
+// BuildPackageWithVariables renders an installed package's manifest at path
+// with variable substitution, returning the prompt file it wrote
+func BuildPackageWithVariables(ctx context.Context, path string, varFlags []string) (string, error) {
+    return buildWithVariables(ctx, path, FlattenManifest, varFlags)
+}
+
+func buildWithVariables(ctx context.Context, path string, flatten func(context.Context, *Manifest) (*Manifest, error), varFlags []string) (string, error) {
     // Parse variables
     vars, err := ParseVarFlags(varFlags)
     if err != nil {
-        return err
+        return "", err
     }
     
     // Load and parse manifest
-    data, err := os.ReadFile(ManifestFile)
+    data, err := os.ReadFile(path)
     if err != nil {
-        return err
+        return "", err
     }
     
     var m Manifest
     if err := yaml.Unmarshal(data, &m); err != nil {
-        return err
+        return "", err
     }
     
     // Validate required fields
     if m.Name == "" || m.Version == "" || m.Licence == "" || m.Prompt == "" {
-        return fmt.Errorf("manifest missing required fields")
+        return "", fmt.Errorf("manifest missing required fields")
+    }
+    
+    // Refuse manifests written for a newer CLI
+    if err := CheckCompatibility(&m); err != nil {
+        return "", err
     }
     
     // Flatten inheritance
-    flattened, err := FlattenManifest(&m)
+    flattened, err := flatten(ctx, &m)
     if err != nil {
-        return err
+        return "", err
     }
     
     // Validate variables
     if err := ValidateVariables(flattened, vars); err != nil {
-        return err
+        return "", err
     }
     
     // Generate persona-aware prompt and then substitute variables
     personaPrompt := GeneratePersonaPrompt(flattened)
     finalPrompt := SubstituteVariables(personaPrompt, vars)
     
+    // Enforce the manifest's token budget
+    if err := CheckTokenBudget(flattened, finalPrompt); err != nil {
+        return "", err
+    }
+    
     // Generate filename based on name and version
     filename := fmt.Sprintf("%s-%s-prompt.md", flattened.Name, flattened.Version)
     
     // Write prompt file
-    if err := os.WriteFile(filename, []byte(finalPrompt), 0644); err != nil {
-        return fmt.Errorf("failed to write %s: %w", filename, err)
+    if err := atomicfile.Write(filename, []byte(finalPrompt), 0644); err != nil {
+        return "", fmt.Errorf("failed to write %s: %w", filename, err)
     }
     
     fmt.Printf("Generated %s with resolved variables\n", filename)
-    return nil
+    return filename, nil
 }
 
 // FetchAndBuild downloads YAML from registry and builds with variables
-func FetchAndBuild(url string, varFlags []string) error {
+func FetchAndBuild(ctx context.Context, url string, varFlags []string) error {
     // Parse variables
     vars, err := ParseVarFlags(varFlags)
     if err != nil {
@@ -290,7 +408,7 @@ func FetchAndBuild(url string, varFlags []string) error {
     }
     
     // Fetch manifest from URL
-    m, err := LoadManifestFromPath(url)
+    m, err := LoadManifestFromPath(ctx, url)
     if err != nil {
         return err
     }
@@ -300,8 +418,13 @@ func FetchAndBuild(url string, varFlags []string) error {
         return fmt.Errorf("manifest missing required fields")
     }
     
+    // Refuse manifests written for a newer CLI
+    if err := CheckCompatibility(m); err != nil {
+        return err
+    }
+    
     // Flatten inheritance
-    flattened, err := FlattenManifest(m)
+    flattened, err := FlattenManifest(ctx, m)
     if err != nil {
         return err
     }
@@ -315,11 +438,16 @@ func FetchAndBuild(url string, varFlags []string) error {
     personaPrompt := GeneratePersonaPrompt(flattened)
     finalPrompt := SubstituteVariables(personaPrompt, vars)
     
+    // Enforce the manifest's token budget
+    if err := CheckTokenBudget(flattened, finalPrompt); err != nil {
+        return err
+    }
+    
     // Generate filename based on name and version
     filename := fmt.Sprintf("%s-%s-prompt.md", flattened.Name, flattened.Version)
     
     // Write prompt file
-    if err := os.WriteFile(filename, []byte(finalPrompt), 0644); err != nil {
+    if err := atomicfile.Write(filename, []byte(finalPrompt), 0644); err != nil {
         return fmt.Errorf("failed to write %s: %w", filename, err)
     }
     
@@ -327,6 +455,46 @@ func FetchAndBuild(url string, varFlags []string) error {
     return nil
 }
 
+// RenderPrompt generates the persona-aware prompt and substitutes vars into it.
+func RenderPrompt(m *Manifest, vars map[string]string) string {
+    return SubstituteVariables(GeneratePersonaPrompt(m), vars)
+}
+
+// ExampleVariables returns each variable's example value, for rendering a
+// representative prompt when no values are supplied.
+func ExampleVariables(m *Manifest) map[string]string {
+    vars := make(map[string]string)
+    for _, v := range m.Variables {
+        if v.Example != "" {
+            vars[v.Name] = v.Example
+        }
+    }
+    return vars
+}
+
+// TokenCount counts the tokens in a rendered prompt using the tokenizer
+// family selected by the manifest's model hint.
+func TokenCount(m *Manifest, rendered string) (int, tokens.Family, error) {
+    family, _ := tokens.ForModel(m.ModelHint)
+    n, err := family.Count(rendered)
+    return n, family, err
+}
+
+// CheckTokenBudget fails if rendered exceeds the manifest's max_tokens_budget.
+func CheckTokenBudget(m *Manifest, rendered string) error {
+    if m.MaxTokensBudget <= 0 {
+        return nil
+    }
+    n, family, err := TokenCount(m, rendered)
+    if err != nil {
+        return err
+    }
+    if n > m.MaxTokensBudget {
+        return fmt.Errorf("rendered prompt is %d tokens (%s), exceeding max_tokens_budget of %d", n, family.Name, m.MaxTokensBudget)
+    }
+    return nil
+}
+
 // GeneratePersonaPrompt creates a persona-aware prompt by combining persona info with the main prompt
 func GeneratePersonaPrompt(m *Manifest) string {
     if m.Persona == nil {
@@ -444,7 +612,7 @@ func GeneratePersonaPrompt(m *Manifest) string {
 }
 
 // GetPromptFilename returns the expected prompt filename for a manifest
-func GetPromptFilename(manifestFile string) (string, error) {
+func GetPromptFilename(ctx context.Context, manifestFile string) (string, error) {
     data, err := os.ReadFile(manifestFile)
     if err != nil {
         return "", err
@@ -456,7 +624,7 @@ func GetPromptFilename(manifestFile string) (string, error) {
     }
     
     // Flatten to get final name/version
-    flattened, err := FlattenManifest(&m)
+    flattened, err := FlattenProjectManifest(ctx, &m)
     if err != nil {
         return "", err
     }
@@ -0,0 +1,82 @@
+package packager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/promptbucket/cli/internal/auth"
+	"github.com/promptbucket/cli/internal/registry"
+)
+
+// registryRefPattern matches a package published to the registry as
+// "org/name:version" or "org/name:@tag", optionally prefixed with the name
+// of the registry as "registry:org/name:version".
+var registryRefPattern = regexp.MustCompile(`^(?:([a-z0-9][a-z0-9_-]*):)?([A-Za-z0-9][A-Za-z0-9._-]*)/([A-Za-z0-9][A-Za-z0-9._-]*):(@?[A-Za-z0-9][A-Za-z0-9._+-]*)$`)
+
+// registryRef is a reference to a published manifest.
+type registryRef struct {
+	// Registry is the registry named in the reference, if any
+	Registry string
+	Org      string
+	Name     string
+	// Version is "@tag" for a dist-tag
+	Version string
+}
+
+// parseRegistryRef parses a registry reference. A local file of the same
+// name takes precedence.
+func parseRegistryRef(source string) (registryRef, bool) {
+	m := registryRefPattern.FindStringSubmatch(source)
+	if m == nil {
+		return registryRef{}, false
+	}
+	if _, err := os.Stat(source); err == nil {
+		return registryRef{}, false
+	}
+	return registryRef{Registry: m[1], Org: m[2], Name: m[3], Version: m[4]}, true
+}
+
+// isRemote reports whether a manifest source is fetched rather than read
+// from the project: a URL or a registry reference.
+func isRemote(source string) bool {
+	if isURL(source) {
+		return true
+	}
+	_, ok := parseRegistryRef(source)
+	return ok
+}
+
+// readRegistryManifest downloads a published manifest from the registry
+// the reference names or routing picks, resolving a dist-tag to the version
+// it points at.
+func readRegistryManifest(ctx context.Context, ref registryRef) ([]byte, error) {
+	org, name, version := ref.Org, ref.Name, ref.Version
+	config := auth.ConfigForPackage(org, name)
+	if ref.Registry != "" {
+		var err error
+		if config, err = auth.ConfigForRegistry(ref.Registry); err != nil {
+			return nil, err
+		}
+	}
+	client := registry.NewFromConfig(config)
+	if strings.HasPrefix(version, "@") {
+		pkg, err := client.Package(ctx, org, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get package info for %s/%s: %w", org, name, err)
+		}
+		v, err := registry.ResolveTag(pkg, version[1:])
+		if err != nil {
+			return nil, err
+		}
+		version = v.Tag
+	}
+
+	data, err := client.Manifest(ctx, org, name, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s/%s:%s: %w", org, name, version, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,419 @@
+// Package registry is a typed client for the PromptBucket registry API.
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/promptbucket/cli/internal/auth"
+	"github.com/promptbucket/cli/internal/cache"
+)
+
+// Client calls registry endpoints through an authenticated API client.
+// Manifests and package details are read through a local cache when one is
+// set, and in offline mode only the cache is used.
+type Client struct {
+	api   *auth.APIClient
+	cache *cache.Cache
+}
+
+// New returns a registry client that sends requests through api, without a
+// cache.
+func New(api *auth.APIClient) *Client {
+	return &Client{api: api}
+}
+
+// NewFromConfig returns a registry client for the configured registry,
+// caching under the configuration directory.
+func NewFromConfig(config *auth.Config) *Client {
+	c := New(auth.NewAPIClient(config))
+	c.cache = cache.InConfigDir(config.ConfigDir)
+	return c
+}
+
+// Error is a non-success response from the registry.
+type Error struct {
+	StatusCode int
+	// Message is the registry's explanation from a "message" or "error"
+	// field, if the body had one.
+	Message string
+	Body    string
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Message != "":
+		return e.Message
+	case e.StatusCode == http.StatusUnauthorized:
+		return "authentication required. Run 'promptbucket login' first"
+	case e.Body != "":
+		return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Body)
+	}
+	return fmt.Sprintf("request failed with status %d", e.StatusCode)
+}
+
+// IsNotFound reports whether err is a 404 response.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsConflict reports whether err is a 409 response, which the registry
+// returns for versions that already exist.
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+// IsForbidden reports whether err is a 403 response, which the registry
+// returns when the user's role does not allow an action.
+func IsForbidden(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
+}
+
+func hasStatus(err error, status int) bool {
+	var e *Error
+	return errors.As(err, &e) && e.StatusCode == status
+}
+
+// decodeError builds an Error from a failed response's body.
+func decodeError(status int, body []byte) error {
+	e := &Error{StatusCode: status, Body: string(bytes.TrimSpace(body))}
+	var fields struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &fields); err == nil {
+		e.Message = fields.Message
+		if e.Message == "" {
+			e.Message = fields.Error
+		}
+	}
+	return e
+}
+
+// requestFunc sends a request through the API client; see
+// auth.APIClient.RequestRaw.
+type requestFunc func(ctx context.Context, method, endpoint, contentType string, body io.Reader) (*http.Response, error)
+
+// do sends a request and returns the body of a 2xx response.
+func (c *Client) do(ctx context.Context, method, endpoint, contentType string, body io.Reader) ([]byte, error) {
+	return c.send(ctx, c.api.RequestRaw, method, endpoint, contentType, body)
+}
+
+// send is do with the API client method that sends the request.
+func (c *Client) send(ctx context.Context, request requestFunc, method, endpoint, contentType string, body io.Reader) ([]byte, error) {
+	if cache.Offline() {
+		return nil, fmt.Errorf("%s: %w", c.api.URL(endpoint), cache.ErrOffline)
+	}
+	resp, err := request(ctx, method, endpoint, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Read response
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, decodeError(resp.StatusCode, data)
+	}
+	return data, nil
+}
+
+// getJSON decodes a GET response into v.
+func (c *Client) getJSON(ctx context.Context, endpoint string, v interface{}) error {
+	data, err := c.do(ctx, "GET", endpoint, "application/json", nil)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// Search finds packages matching a query and filters.
+func (c *Client) Search(ctx context.Context, req SearchRequest) (*PackageList, error) {
+	q := req.ListOptions.values()
+	q.Set("q", req.Query)
+	for _, tag := range req.Tags {
+		q.Add("tag", tag)
+	}
+	setIf(q, "author", req.Author)
+	setIf(q, "org", req.Org)
+	setIf(q, "language", req.Language)
+	setIf(q, "model_hint", req.ModelHint)
+	setIf(q, "licence", req.Licence)
+	setIf(q, "sort", req.Sort)
+	return c.listPackages(ctx, "/packages/search", q)
+}
+
+// Popular lists the most starred and pulled packages.
+func (c *Client) Popular(ctx context.Context, opts ListOptions) (*PackageList, error) {
+	return c.listPackages(ctx, "/packages/popular", opts.values())
+}
+
+// Trending lists packages gaining stars and pulls recently.
+func (c *Client) Trending(ctx context.Context, req TrendingRequest) (*PackageList, error) {
+	q := req.ListOptions.values()
+	if !req.Since.IsZero() {
+		q.Set("since", req.Since.UTC().Format(time.RFC3339))
+	}
+	return c.listPackages(ctx, "/packages/trending", q)
+}
+
+func setIf(q url.Values, key, value string) {
+	if value != "" {
+		q.Set(key, value)
+	}
+}
+
+// values encodes the paging parameters, omitting defaults.
+func (o ListOptions) values() url.Values {
+	q := url.Values{}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Offset > 0 {
+		q.Set("offset", strconv.Itoa(o.Offset))
+	}
+	return q
+}
+
+// listPackages decodes listings, which the registry returns either wrapped
+// in a "results" object with paging fields or as a bare array holding every
+// result.
+func (c *Client) listPackages(ctx context.Context, endpoint string, q url.Values) (*PackageList, error) {
+	if len(q) > 0 {
+		endpoint += "?" + q.Encode()
+	}
+	var raw json.RawMessage
+	if err := c.getJSON(ctx, endpoint, &raw); err != nil {
+		return nil, err
+	}
+
+	var list PackageList
+	if err := json.Unmarshal(raw, &list); err == nil && list.Results != nil {
+		return &list, nil
+	}
+	var packages []Package
+	if err := json.Unmarshal(raw, &packages); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &PackageList{Results: packages, Total: len(packages), Limit: len(packages)}, nil
+}
+
+// packagePath returns the escaped endpoint for a package.
+func packagePath(org, name string) string {
+	return "/packages/" + url.PathEscape(org) + "/" + url.PathEscape(name)
+}
+
+// manifestPath returns the escaped endpoint for a package version's manifest.
+func manifestPath(org, name, version string) string {
+	return "/manifests/" + url.PathEscape(org) + "/" + url.PathEscape(name) + "/" + url.PathEscape(version)
+}
+
+// Package returns a package's details and published versions. Offline, the
+// details last fetched are returned.
+func (c *Client) Package(ctx context.Context, org, name string) (*Package, error) {
+	endpoint := packagePath(org, name)
+	var data []byte
+	if cached, ok := c.cached(endpoint); ok && cache.Offline() {
+		data = cached
+	} else {
+		body, err := c.do(ctx, "GET", endpoint, "application/json", nil)
+		if err != nil {
+			return nil, err
+		}
+		data = body
+		c.store(endpoint, data, false)
+	}
+
+	var pkg Package
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if pkg.Org == "" {
+		pkg.Org = org
+	}
+	if pkg.Name == "" {
+		pkg.Name = name
+	}
+	return &pkg, nil
+}
+
+// Manifest downloads the YAML manifest of a package version. Published
+// versions never change, so a cached copy is used whenever there is one.
+func (c *Client) Manifest(ctx context.Context, org, name, version string) ([]byte, error) {
+	endpoint := manifestPath(org, name, version)
+	if data, ok := c.cached(endpoint); ok {
+		return data, nil
+	}
+	data, err := c.do(ctx, "GET", endpoint, "application/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	c.store(endpoint, data, true)
+	return data, nil
+}
+
+// cached returns the cached response for an endpoint, keyed by its URL so
+// that `fetch` of the same URL shares the entry.
+func (c *Client) cached(endpoint string) ([]byte, bool) {
+	if c.cache == nil {
+		return nil, false
+	}
+	data, _, ok := c.cache.Get(c.api.URL(endpoint))
+	return data, ok
+}
+
+// store caches a response. A cache that cannot be written only costs a
+// later download, so errors are ignored.
+func (c *Client) store(endpoint string, data []byte, immutable bool) {
+	if c.cache != nil {
+		c.cache.Put(c.api.URL(endpoint), data, cache.Entry{Immutable: immutable})
+	}
+}
+
+// Push uploads a manifest as a new package version.
+func (c *Client) Push(ctx context.Context, req PushRequest) error {
+	// Versions cannot be overwritten, so a retry after a lost response
+	// would fail with a conflict even though the push succeeded
+	_, err := c.send(ctx, c.api.RequestOnce, "PUT", pushEndpoint(req), pushContentType, bytes.NewReader(req.Manifest))
+	return err
+}
+
+// pushContentType is the content type manifests are uploaded as.
+const pushContentType = "application/x-yaml"
+
+// pushEndpoint returns the endpoint Push sends a request to.
+func pushEndpoint(req PushRequest) string {
+	endpoint := manifestPath(req.Org, req.Name, req.Version)
+	q := url.Values{}
+	setIf(q, "tag", req.Tag)
+	setIf(q, "visibility", req.Visibility)
+	if len(q) > 0 {
+		endpoint += "?" + q.Encode()
+	}
+	return endpoint
+}
+
+// PushPreview returns the request Push would send, without sending it. The
+// request's body is the manifest.
+func (c *Client) PushPreview(ctx context.Context, req PushRequest) (*http.Request, error) {
+	r, err := http.NewRequestWithContext(ctx, "PUT", c.api.URL(pushEndpoint(req)), bytes.NewReader(req.Manifest))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	headers, err := c.api.Headers(pushContentType)
+	if err != nil {
+		return nil, err
+	}
+	r.Header = headers
+	return r, nil
+}
+
+// SetVisibility changes who can see and pull a package.
+func (c *Client) SetVisibility(ctx context.Context, org, name, visibility string) error {
+	body, err := json.Marshal(map[string]string{"visibility": visibility})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request body: %w", err)
+	}
+	_, err = c.do(ctx, "PATCH", packagePath(org, name), "application/json", bytes.NewReader(body))
+	return err
+}
+
+// Delete removes a package and every version of it from the registry.
+func (c *Client) Delete(ctx context.Context, org, name string) error {
+	_, err := c.do(ctx, "DELETE", packagePath(org, name), "application/json", nil)
+	return err
+}
+
+// DeleteVersion removes one published version of a package.
+func (c *Client) DeleteVersion(ctx context.Context, org, name, version string) error {
+	_, err := c.do(ctx, "DELETE", versionPath(org, name, version), "application/json", nil)
+	return err
+}
+
+// distTagPath returns the escaped endpoint for a package's dist-tag.
+func distTagPath(org, name, tag string) string {
+	return packagePath(org, name) + "/dist-tags/" + url.PathEscape(tag)
+}
+
+// SetDistTag points a dist-tag at a published version, moving it if it
+// already exists.
+func (c *Client) SetDistTag(ctx context.Context, org, name, tag, version string) error {
+	body, err := json.Marshal(map[string]string{"version": version})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request body: %w", err)
+	}
+	_, err = c.do(ctx, "PUT", distTagPath(org, name, tag), "application/json", bytes.NewReader(body))
+	return err
+}
+
+// RemoveDistTag deletes a dist-tag. The version it pointed at is unaffected.
+func (c *Client) RemoveDistTag(ctx context.Context, org, name, tag string) error {
+	_, err := c.do(ctx, "DELETE", distTagPath(org, name, tag), "application/json", nil)
+	return err
+}
+
+// versionPath returns the escaped endpoint for a published version.
+func versionPath(org, name, version string) string {
+	return packagePath(org, name) + "/versions/" + url.PathEscape(version)
+}
+
+// Deprecate marks a version as deprecated with a message shown to anyone
+// who pulls or installs it. An empty message removes the deprecation.
+func (c *Client) Deprecate(ctx context.Context, org, name, version, message string) error {
+	endpoint := versionPath(org, name, version) + "/deprecation"
+	if message == "" {
+		_, err := c.do(ctx, "DELETE", endpoint, "application/json", nil)
+		return err
+	}
+	body, err := json.Marshal(map[string]string{"message": message})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request body: %w", err)
+	}
+	_, err = c.do(ctx, "PUT", endpoint, "application/json", bytes.NewReader(body))
+	return err
+}
+
+// Yank hides a version from range resolution. It can still be pulled by
+// its exact version, so existing pins keep working.
+func (c *Client) Yank(ctx context.Context, org, name, version string) error {
+	_, err := c.do(ctx, "PUT", versionPath(org, name, version)+"/yank", "application/json", nil)
+	return err
+}
+
+// Unyank reverses Yank.
+func (c *Client) Unyank(ctx context.Context, org, name, version string) error {
+	_, err := c.do(ctx, "DELETE", versionPath(org, name, version)+"/yank", "application/json", nil)
+	return err
+}
+
+// Star stars a package for the current user.
+func (c *Client) Star(ctx context.Context, org, name string) error {
+	_, err := c.do(ctx, "POST", packagePath(org, name)+"/star", "application/json", nil)
+	return err
+}
+
+// Unstar removes the current user's star from a package.
+func (c *Client) Unstar(ctx context.Context, org, name string) error {
+	_, err := c.do(ctx, "DELETE", packagePath(org, name)+"/star", "application/json", nil)
+	return err
+}
+
+// Starred lists the packages the current user has starred.
+func (c *Client) Starred(ctx context.Context, opts ListOptions) (*PackageList, error) {
+	return c.listPackages(ctx, "/user/starred", opts.values())
+}
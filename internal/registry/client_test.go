@@ -0,0 +1,276 @@
+package registry_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/promptbucket/cli/internal/auth"
+	"github.com/promptbucket/cli/internal/registry"
+	"github.com/promptbucket/cli/internal/registry/registrytest"
+)
+
+// rawClient returns a client for a server that answers every request with
+// status and body.
+func rawClient(t *testing.T, status int, body string) *registry.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	config := auth.NewConfig()
+	config.BaseURL = server.URL
+	config.APIVersion = "v1"
+	config.ConfigDir = t.TempDir()
+	return registry.NewFromConfig(config)
+}
+
+func TestErrorDecoding(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"message field", 400, `{"message": "name is required"}`, "name is required"},
+		{"error field", 403, `{"error": "not allowed"}`, "not allowed"},
+		{"message wins over error", 400, `{"message": "first", "error": "second"}`, "first"},
+		{"plain text body", 404, "no such thing\n", "request failed with status 404: no such thing"},
+		{"empty body", 410, "", "request failed with status 410"},
+		{"unauthorized", 401, "", "authentication required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := rawClient(t, tt.status, tt.body).Package(context.Background(), "acme", "demo")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want one containing %q", err, tt.want)
+			}
+			var e *registry.Error
+			if !errors.As(err, &e) || e.StatusCode != tt.status {
+				t.Errorf("err = %#v, want a registry.Error with status %d", err, tt.status)
+			}
+		})
+	}
+}
+
+func TestPackageOrgDecoding(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"org", `{"name": "demo", "org": "acme"}`, "acme"},
+		{"org_name", `{"name": "demo", "org_name": "acme"}`, "acme"},
+		{"org_id", `{"name": "demo", "org_id": "acme"}`, "acme"},
+		{"org wins", `{"name": "demo", "org": "acme", "org_name": "other"}`, "acme"},
+		{"missing falls back to the request", `{"name": "demo"}`, "fallback"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, err := rawClient(t, 200, tt.body).Package(context.Background(), "fallback", "demo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pkg.Org != tt.want {
+				t.Errorf("Org = %q, want %q", pkg.Org, tt.want)
+			}
+		})
+	}
+}
+
+func TestListShapes(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		names []string
+		total int
+	}{
+		{"wrapped", `{"results": [{"name": "a", "org_name": "acme"}], "total": 7, "limit": 1, "offset": 2}`, []string{"acme/a"}, 7},
+		{"wrapped empty", `{"results": [], "total": 0}`, []string{}, 0},
+		{"bare array", `[{"name": "a", "org": "acme"}, {"name": "b", "org_id": "acme"}]`, []string{"acme/a", "acme/b"}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := rawClient(t, 200, tt.body).Popular(context.Background(), registry.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := []string{}
+			for _, p := range list.Results {
+				names = append(names, p.FullName())
+			}
+			if !reflect.DeepEqual(names, tt.names) || list.Total != tt.total {
+				t.Errorf("got %v (total %d), want %v (total %d)", names, list.Total, tt.names, tt.total)
+			}
+		})
+	}
+}
+
+func TestSearchPaging(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		server.AddPackage(registry.Package{Name: name, Org: "acme", Description: "prompt " + name})
+	}
+	client := server.Client(t.TempDir())
+	ctx := context.Background()
+
+	list, err := client.Search(ctx, registry.SearchRequest{Query: "prompt", ListOptions: registry.ListOptions{Limit: 2, Offset: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Results) != 2 || list.Results[0].Name != "c" || list.Total != 5 || list.Limit != 2 || list.Offset != 2 {
+		t.Errorf("Search() = %+v, want c and d of 5", list)
+	}
+	if want := "GET /v1/packages/search?limit=2&offset=2&q=prompt"; server.Requests[len(server.Requests)-1] != want {
+		t.Errorf("request = %s, want %s", server.Requests[len(server.Requests)-1], want)
+	}
+
+	// The iterator walks every page
+	var names []string
+	it := registry.Iterate(client.Popular, registry.ListOptions{Limit: 2})
+	for it.Next(ctx) {
+		names = append(names, it.Package().Name)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b", "c", "d", "e"}; !reflect.DeepEqual(names, want) || it.Total() != 5 {
+		t.Errorf("iterated %v (total %d), want %v", names, it.Total(), want)
+	}
+}
+
+func TestPushAndPull(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	client := server.Client(t.TempDir())
+	ctx := context.Background()
+
+	manifest := []byte("name: demo\nversion: 1.0.0\nprompt: Hello\n")
+	req := registry.PushRequest{Org: "acme", Name: "demo", Version: "1.0.0", Manifest: manifest, Visibility: registry.VisibilityPrivate}
+	if err := client.Push(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Push(ctx, req); !registry.IsConflict(err) {
+		t.Errorf("second Push() = %v, want a conflict", err)
+	}
+
+	got, err := client.Manifest(ctx, "acme", "demo", "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(manifest) {
+		t.Errorf("Manifest() = %q, want %q", got, manifest)
+	}
+	pkg, err := client.Package(ctx, "acme", "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.Visibility != registry.VisibilityPrivate || pkg.DistTags[registry.LatestTag] != "1.0.0" {
+		t.Errorf("Package() = %+v, want a private package tagged latest", pkg)
+	}
+
+	if _, err := client.Manifest(ctx, "acme", "demo", "9.9.9"); !registry.IsNotFound(err) {
+		t.Errorf("Manifest() of a missing version = %v, want not found", err)
+	}
+}
+
+func TestDistTags(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	server.AddManifest("acme", "demo", "1.0.0", []byte("name: demo\n"))
+	server.AddManifest("acme", "demo", "1.1.0-beta.1", []byte("name: demo\n"))
+	client := server.Client(t.TempDir())
+	ctx := context.Background()
+
+	if err := client.SetDistTag(ctx, "acme", "demo", "beta", "1.1.0-beta.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SetDistTag(ctx, "acme", "demo", "beta", "2.0.0"); !registry.IsNotFound(err) {
+		t.Errorf("SetDistTag() to a missing version = %v, want not found", err)
+	}
+	pkg, err := client.Package(ctx, "acme", "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := registry.Resolve(pkg, "@beta"); err != nil || v.Tag != "1.1.0-beta.1" {
+		t.Errorf("Resolve(@beta) = %v, %v; want 1.1.0-beta.1", v.Tag, err)
+	}
+
+	if err := client.RemoveDistTag(ctx, "acme", "demo", "beta"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.RemoveDistTag(ctx, "acme", "demo", "beta"); !registry.IsNotFound(err) {
+		t.Errorf("second RemoveDistTag() = %v, want not found", err)
+	}
+	if pkg, _ = client.Package(ctx, "acme", "demo"); pkg.DistTags["beta"] != "" {
+		t.Errorf("dist-tags = %v, want beta removed", pkg.DistTags)
+	}
+}
+
+func TestDeprecateAndYank(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	server.AddManifest("acme", "demo", "1.0.0", []byte("name: demo\n"))
+	server.AddManifest("acme", "demo", "1.1.0", []byte("name: demo\n"))
+	client := server.Client(t.TempDir())
+	ctx := context.Background()
+
+	version := func(tag string) registry.Version {
+		t.Helper()
+		pkg, err := client.Package(ctx, "acme", "demo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range pkg.Versions {
+			if v.Tag == tag {
+				return v
+			}
+		}
+		t.Fatalf("version %s not listed", tag)
+		return registry.Version{}
+	}
+
+	if err := client.Deprecate(ctx, "acme", "demo", "1.0.0", "use 1.1.0"); err != nil {
+		t.Fatal(err)
+	}
+	if got := version("1.0.0").Deprecated; got != "use 1.1.0" {
+		t.Errorf("Deprecated = %q, want the message", got)
+	}
+	if err := client.Deprecate(ctx, "acme", "demo", "1.0.0", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := version("1.0.0").Deprecated; got != "" {
+		t.Errorf("Deprecated = %q after undeprecating, want empty", got)
+	}
+	if err := client.Deprecate(ctx, "acme", "demo", "3.0.0", "gone"); !registry.IsNotFound(err) {
+		t.Errorf("Deprecate() of a missing version = %v, want not found", err)
+	}
+
+	if err := client.Yank(ctx, "acme", "demo", "1.1.0"); err != nil {
+		t.Fatal(err)
+	}
+	if !version("1.1.0").Yanked {
+		t.Error("1.1.0 is not yanked")
+	}
+	pkg, _ := client.Package(ctx, "acme", "demo")
+	if v, err := registry.Resolve(pkg, "^1"); err != nil || v.Tag != "1.0.0" {
+		t.Errorf("Resolve(^1) = %v, %v; want the yanked 1.1.0 skipped", v.Tag, err)
+	}
+	if err := client.Unyank(ctx, "acme", "demo", "1.1.0"); err != nil {
+		t.Fatal(err)
+	}
+	if version("1.1.0").Yanked {
+		t.Error("1.1.0 is still yanked")
+	}
+}
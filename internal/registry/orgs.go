@@ -0,0 +1,159 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// Organization member roles, from most to least privileged.
+const (
+	// RoleOwner can do everything, including deleting the org.
+	RoleOwner = "owner"
+	// RoleAdmin manages members and packages.
+	RoleAdmin = "admin"
+	// RoleWriter publishes packages to the org namespace.
+	RoleWriter = "writer"
+	// RoleReader can see the org's private packages.
+	RoleReader = "reader"
+)
+
+// Roles are the accepted member roles, most privileged first.
+var Roles = []string{RoleOwner, RoleAdmin, RoleWriter, RoleReader}
+
+// CanPublish reports whether a member with role may push packages.
+func CanPublish(role string) bool {
+	return role == RoleOwner || role == RoleAdmin || role == RoleWriter
+}
+
+// Profile is the signed-in user as the registry knows them.
+type Profile struct {
+	// Username is the user's personal namespace.
+	Username string `json:"username"`
+	Email    string `json:"email,omitempty"`
+	Name     string `json:"name,omitempty"`
+	// Orgs lists the organizations the user belongs to.
+	Orgs []Membership `json:"orgs,omitempty"`
+}
+
+// Membership is a user's role in an organization.
+type Membership struct {
+	Org  string `json:"org"`
+	Role string `json:"role"`
+}
+
+// Role returns the user's role in an org, or "" if they are not a member.
+func (p *Profile) Role(org string) string {
+	for _, m := range p.Orgs {
+		if m.Org == org {
+			return m.Role
+		}
+	}
+	return ""
+}
+
+// Org is an organization namespace.
+type Org struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name,omitempty"`
+	// Role is the signed-in user's role in the org.
+	Role         string `json:"role,omitempty"`
+	MemberCount  int    `json:"member_count,omitempty"`
+	PackageCount int    `json:"package_count,omitempty"`
+	CreatedAt    string `json:"created_at,omitempty"`
+}
+
+// Member is a user's membership as listed by an org.
+type Member struct {
+	Username string `json:"username"`
+	Email    string `json:"email,omitempty"`
+	Role     string `json:"role"`
+}
+
+// orgPath returns the escaped endpoint for an organization.
+func orgPath(org string) string {
+	return "/orgs/" + url.PathEscape(org)
+}
+
+// memberPath returns the escaped endpoint for an org member.
+func memberPath(org, username string) string {
+	return orgPath(org) + "/members/" + url.PathEscape(username)
+}
+
+// Profile returns the signed-in user's profile, including their namespace
+// and org memberships.
+func (c *Client) Profile(ctx context.Context) (*Profile, error) {
+	var p Profile
+	if err := c.getJSON(ctx, "/user/profile", &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Orgs lists the organizations the signed-in user belongs to.
+func (c *Client) Orgs(ctx context.Context) ([]Org, error) {
+	var resp struct {
+		Results []Org `json:"results"`
+	}
+	if err := c.getJSON(ctx, "/user/orgs", &resp); err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+// CreateOrg creates an organization owned by the signed-in user.
+func (c *Client) CreateOrg(ctx context.Context, name, displayName string) (*Org, error) {
+	body, err := json.Marshal(Org{Name: name, DisplayName: displayName})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+	data, err := c.do(ctx, "POST", "/orgs", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	org := Org{Name: name, DisplayName: displayName, Role: RoleOwner}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &org); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+	return &org, nil
+}
+
+// Members lists an organization's members.
+func (c *Client) Members(ctx context.Context, org string) ([]Member, error) {
+	var resp struct {
+		Results []Member `json:"results"`
+	}
+	if err := c.getJSON(ctx, orgPath(org)+"/members", &resp); err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+// AddMember adds a user to an organization with a role.
+func (c *Client) AddMember(ctx context.Context, org, username, role string) error {
+	return c.sendRole(ctx, "PUT", org, username, role)
+}
+
+// SetRole changes an existing member's role.
+func (c *Client) SetRole(ctx context.Context, org, username, role string) error {
+	return c.sendRole(ctx, "PATCH", org, username, role)
+}
+
+// RemoveMember removes a user from an organization.
+func (c *Client) RemoveMember(ctx context.Context, org, username string) error {
+	_, err := c.do(ctx, "DELETE", memberPath(org, username), "application/json", nil)
+	return err
+}
+
+func (c *Client) sendRole(ctx context.Context, method, org, username, role string) error {
+	body, err := json.Marshal(map[string]string{"role": role})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request body: %w", err)
+	}
+	_, err = c.do(ctx, method, memberPath(org, username), "application/json", bytes.NewReader(body))
+	return err
+}
@@ -0,0 +1,100 @@
+package registry
+
+import "context"
+
+// DefaultPageSize is the page size Iterator requests when none is given.
+const DefaultPageSize = 50
+
+// ListFunc fetches one page of a listing, such as Client.Popular or a
+// closure over Client.Search.
+type ListFunc func(context.Context, ListOptions) (*PackageList, error)
+
+// Iterator walks every result of a listing, fetching pages lazily as they
+// are reached:
+//
+//	it := registry.Iterate(client.Popular, registry.ListOptions{})
+//	for it.Next(ctx) {
+//		pkg := it.Package()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator struct {
+	list  ListFunc
+	opts  ListOptions
+	page  []Package
+	pos   int
+	total int
+	done  bool
+	err   error
+	cur   Package
+}
+
+// Iterate returns an iterator over list starting at opts.Offset, fetching
+// opts.Limit results per request.
+func Iterate(list ListFunc, opts ListOptions) *Iterator {
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultPageSize
+	}
+	return &Iterator{list: list, opts: opts}
+}
+
+// Next advances to the next result, fetching another page if needed. It
+// returns false when the listing is exhausted or a request failed.
+func (it *Iterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	if it.pos >= len(it.page) {
+		if it.done {
+			return false
+		}
+		it.fetch(ctx)
+		if it.err != nil || len(it.page) == 0 {
+			return false
+		}
+	}
+	it.cur = it.page[it.pos]
+	it.pos++
+	return true
+}
+
+func (it *Iterator) fetch(ctx context.Context) {
+	list, err := it.list(ctx, it.opts)
+	if err != nil {
+		it.err = err
+		return
+	}
+	it.page, it.pos = list.Results, 0
+	it.total = list.Total
+	it.opts.Offset += len(list.Results)
+
+	// Registries may cap the page size below the limit asked for, so a
+	// short page only ends the listing when there is no total to go by.
+	// Listings that ignore paging return everything at once and end here.
+	switch {
+	case len(list.Results) == 0:
+		it.done = true
+	case list.Total > 0:
+		it.done = it.opts.Offset >= list.Total
+	default:
+		it.done = len(list.Results) < it.opts.Limit
+	}
+}
+
+// Package returns the current result.
+func (it *Iterator) Package() Package {
+	return it.cur
+}
+
+// Total returns the total number of results the registry reported, once
+// the first page has been fetched.
+func (it *Iterator) Total() int {
+	return it.total
+}
+
+// Err returns the error that stopped iteration, if any.
+func (it *Iterator) Err() error {
+	return it.err
+}
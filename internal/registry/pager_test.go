@@ -0,0 +1,89 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// cappedList returns a ListFunc over n packages that serves at most max
+// results per page, reporting the total only if withTotal is set.
+func cappedList(n, max int, withTotal bool, calls *int) ListFunc {
+	return func(ctx context.Context, opts ListOptions) (*PackageList, error) {
+		*calls++
+		limit := opts.Limit
+		if limit > max {
+			limit = max
+		}
+		list := &PackageList{Results: []Package{}, Limit: limit, Offset: opts.Offset}
+		if withTotal {
+			list.Total = n
+		}
+		for i := opts.Offset; i < n && i < opts.Offset+limit; i++ {
+			list.Results = append(list.Results, Package{Name: fmt.Sprintf("p%d", i)})
+		}
+		return list, nil
+	}
+}
+
+func TestIterator(t *testing.T) {
+	tests := []struct {
+		name      string
+		n, max    int
+		limit     int
+		withTotal bool
+		want      int
+		calls     int
+	}{
+		{"pages within the cap", 5, 100, 2, true, 5, 3},
+		{"registry caps the page size", 250, 100, 500, true, 250, 3},
+		{"exact pages end on the total", 4, 100, 2, true, 4, 2},
+		{"no total ends on a short page", 5, 100, 2, false, 5, 3},
+		{"no total and exact pages end on an empty page", 4, 100, 2, false, 4, 3},
+		{"empty listing", 0, 100, 10, true, 0, 1},
+	}
+
+	for _, tt := range tests {
+		var calls int
+		it := Iterate(cappedList(tt.n, tt.max, tt.withTotal, &calls), ListOptions{Limit: tt.limit})
+		var names []string
+		for it.Next(context.Background()) {
+			names = append(names, it.Package().Name)
+		}
+		if err := it.Err(); err != nil {
+			t.Fatal(err)
+		}
+
+		var want []string
+		for i := 0; i < tt.want; i++ {
+			want = append(want, fmt.Sprintf("p%d", i))
+		}
+		if !reflect.DeepEqual(names, want) {
+			t.Errorf("%s: iterated %d result(s), want %d", tt.name, len(names), tt.want)
+		}
+		if calls != tt.calls {
+			t.Errorf("%s: fetched %d page(s), want %d", tt.name, calls, tt.calls)
+		}
+	}
+}
+
+func TestIteratorError(t *testing.T) {
+	fail := fmt.Errorf("registry unavailable")
+	var calls int
+	list := cappedList(10, 2, true, &calls)
+	it := Iterate(func(ctx context.Context, opts ListOptions) (*PackageList, error) {
+		if opts.Offset >= 4 {
+			return nil, fail
+		}
+		return list(ctx, opts)
+	}, ListOptions{Limit: 2})
+
+	n := 0
+	for it.Next(context.Background()) {
+		n++
+	}
+	if n != 4 || it.Err() != fail {
+		t.Errorf("iterated %d result(s), err = %v; want 4 and %v", n, it.Err(), fail)
+	}
+}
@@ -0,0 +1,620 @@
+// Package registrytest provides an in-memory registry served over
+// httptest, for exercising the registry client and commands without the
+// network. Listings are paged and filtered by the same code as the
+// registry server in package server.
+package registrytest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/promptbucket/cli/internal/auth"
+	"github.com/promptbucket/cli/internal/registry"
+	"github.com/promptbucket/cli/internal/registry/server"
+)
+
+// Server is a fake registry. Its fields may be inspected after requests;
+// use the methods to modify it while it is serving.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	packages  map[string]*registry.Package
+	manifests map[string][]byte
+	starred   map[string]bool
+	profile   registry.Profile
+	// orgs maps each org to its members' roles by username.
+	orgs map[string]map[string]string
+	// Requests records "METHOD /path?query" for every request received.
+	Requests []string
+}
+
+// NewServer starts a fake registry. Call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		packages:  map[string]*registry.Package{},
+		manifests: map[string][]byte{},
+		starred:   map[string]bool{},
+		profile:   registry.Profile{Username: "tester", Email: "tester@example.com"},
+		orgs:      map[string]map[string]string{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/packages/search", s.search)
+	mux.HandleFunc("GET /v1/packages/popular", s.popular)
+	mux.HandleFunc("GET /v1/packages/trending", s.trending)
+	mux.HandleFunc("GET /v1/packages/{org}/{name}", s.getPackage)
+	mux.HandleFunc("PATCH /v1/packages/{org}/{name}", s.patchPackage)
+	mux.HandleFunc("DELETE /v1/packages/{org}/{name}", s.deletePackage)
+	mux.HandleFunc("DELETE /v1/packages/{org}/{name}/versions/{version}", s.deleteVersion)
+	mux.HandleFunc("PUT /v1/packages/{org}/{name}/dist-tags/{tag}", s.putDistTag)
+	mux.HandleFunc("DELETE /v1/packages/{org}/{name}/dist-tags/{tag}", s.deleteDistTag)
+	mux.HandleFunc("POST /v1/packages/{org}/{name}/star", s.star)
+	mux.HandleFunc("DELETE /v1/packages/{org}/{name}/star", s.star)
+	mux.HandleFunc("PUT /v1/packages/{org}/{name}/versions/{version}/deprecation", s.deprecate)
+	mux.HandleFunc("DELETE /v1/packages/{org}/{name}/versions/{version}/deprecation", s.deprecate)
+	mux.HandleFunc("PUT /v1/packages/{org}/{name}/versions/{version}/yank", s.yank)
+	mux.HandleFunc("DELETE /v1/packages/{org}/{name}/versions/{version}/yank", s.yank)
+	mux.HandleFunc("GET /v1/user/starred", s.listStarred)
+	mux.HandleFunc("GET /v1/user/profile", s.getProfile)
+	mux.HandleFunc("GET /v1/user/orgs", s.listOrgs)
+	mux.HandleFunc("POST /v1/orgs", s.createOrg)
+	mux.HandleFunc("GET /v1/orgs/{org}/members", s.listMembers)
+	mux.HandleFunc("PUT /v1/orgs/{org}/members/{user}", s.putMember)
+	mux.HandleFunc("PATCH /v1/orgs/{org}/members/{user}", s.putMember)
+	mux.HandleFunc("DELETE /v1/orgs/{org}/members/{user}", s.deleteMember)
+	mux.HandleFunc("GET /v1/manifests/{org}/{name}/{version}", s.getManifest)
+	mux.HandleFunc("PUT /v1/manifests/{org}/{name}/{version}", s.putManifest)
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.Requests = append(s.Requests, r.Method+" "+r.URL.RequestURI())
+		s.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+	return s
+}
+
+// Config returns auth configuration pointing at the server, storing
+// credentials under dir.
+func (s *Server) Config(dir string) *auth.Config {
+	config := auth.NewConfig()
+	config.BaseURL = s.URL
+	config.APIVersion = "v1"
+	config.ConfigDir = filepath.Join(dir, ".promptbucket")
+	return config
+}
+
+// Client returns a registry client for the server, storing credentials
+// under dir.
+func (s *Server) Client(dir string) *registry.Client {
+	return registry.NewFromConfig(s.Config(dir))
+}
+
+// AddPackage registers a package, replacing any with the same org and name.
+func (s *Server) AddPackage(p registry.Package) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.packages[p.FullName()] = &p
+}
+
+// AddManifest publishes a manifest as a version of org/name, creating the
+// package if needed.
+func (s *Server) AddManifest(org, name, version string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addManifest(org, name, version, data)
+}
+
+func (s *Server) addManifest(org, name, version string, data []byte) {
+	key := org + "/" + name
+	pkg, ok := s.packages[key]
+	if !ok {
+		pkg = &registry.Package{ID: key, Name: name, Org: org, Visibility: registry.VisibilityPublic, CreatedAt: time.Now().UTC().Format(time.RFC3339)}
+		s.packages[key] = pkg
+	}
+	// Newest first, as the registry lists them
+	hash := sha256.Sum256(data)
+	v := registry.Version{Tag: version, Digest: "sha256:" + hex.EncodeToString(hash[:]), CreatedAt: time.Now().UTC().Format(time.RFC3339)}
+	pkg.Versions = append([]registry.Version{v}, pkg.Versions...)
+	pkg.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	s.manifests[key+"/"+version] = data
+}
+
+// SetUser sets the username requests are made as. It defaults to "tester".
+func (s *Server) SetUser(username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profile.Username = username
+	s.profile.Email = username + "@example.com"
+}
+
+// AddOrg creates an org with members' roles by username, replacing any
+// org with the same name.
+func (s *Server) AddOrg(name string, members map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	roles := map[string]string{}
+	for user, role := range members {
+		roles[user] = role
+	}
+	s.orgs[name] = roles
+}
+
+// SetDistTag points a dist-tag of org/name at a version.
+func (s *Server) SetDistTag(org, name, tag, version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pkg := s.packages[org+"/"+name]
+	if pkg.DistTags == nil {
+		pkg.DistTags = map[string]string{}
+	}
+	pkg.DistTags[tag] = version
+}
+
+// Manifest returns a published manifest, or nil.
+func (s *Server) Manifest(org, name, version string) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.manifests[org+"/"+name+"/"+version]
+}
+
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	server.ServeSearch(w, r, s.sortedPackages())
+}
+
+func (s *Server) popular(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	server.ServePopular(w, r, s.sortedPackages())
+}
+
+func (s *Server) trending(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	server.ServeTrending(w, r, s.sortedPackages())
+}
+
+func (s *Server) getPackage(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := r.PathValue("org") + "/" + r.PathValue("name")
+	pkg, ok := s.packages[key]
+	if !ok {
+		server.WriteError(w, http.StatusNotFound, "package not found")
+		return
+	}
+	p := *pkg
+	p.HasStarred = s.starred[key]
+	server.WriteJSON(w, http.StatusOK, p)
+}
+
+func (s *Server) patchPackage(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Visibility string `json:"visibility"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || !validVisibility(body.Visibility) {
+		server.WriteError(w, http.StatusBadRequest, "visibility must be private, org or public")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pkg, ok := s.packages[r.PathValue("org")+"/"+r.PathValue("name")]
+	if !ok {
+		server.WriteError(w, http.StatusNotFound, "package not found")
+		return
+	}
+	pkg.Visibility = body.Visibility
+	server.WriteJSON(w, http.StatusOK, pkg)
+}
+
+func (s *Server) deletePackage(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := r.PathValue("org") + "/" + r.PathValue("name")
+	pkg, ok := s.packages[key]
+	if !ok {
+		server.WriteError(w, http.StatusNotFound, "package not found")
+		return
+	}
+	for _, v := range pkg.Versions {
+		delete(s.manifests, key+"/"+v.Tag)
+	}
+	delete(s.packages, key)
+	delete(s.starred, key)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteVersion(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := r.PathValue("org") + "/" + r.PathValue("name")
+	if s.version(w, r) == nil {
+		return
+	}
+	pkg := s.packages[key]
+	for i, v := range pkg.Versions {
+		if v.Tag == r.PathValue("version") {
+			pkg.Versions = append(pkg.Versions[:i], pkg.Versions[i+1:]...)
+			break
+		}
+	}
+	for tag, version := range pkg.DistTags {
+		if version == r.PathValue("version") {
+			delete(pkg.DistTags, tag)
+		}
+	}
+	delete(s.manifests, key+"/"+r.PathValue("version"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) putDistTag(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Version == "" {
+		server.WriteError(w, http.StatusBadRequest, "version is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := r.PathValue("org") + "/" + r.PathValue("name")
+	pkg, ok := s.packages[key]
+	if !ok {
+		server.WriteError(w, http.StatusNotFound, "package not found")
+		return
+	}
+	if _, ok := s.manifests[key+"/"+body.Version]; !ok {
+		server.WriteError(w, http.StatusNotFound, "version not found")
+		return
+	}
+	if pkg.DistTags == nil {
+		pkg.DistTags = map[string]string{}
+	}
+	pkg.DistTags[r.PathValue("tag")] = body.Version
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteDistTag(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pkg, ok := s.packages[r.PathValue("org")+"/"+r.PathValue("name")]
+	if !ok {
+		server.WriteError(w, http.StatusNotFound, "package not found")
+		return
+	}
+	if _, ok := pkg.DistTags[r.PathValue("tag")]; !ok {
+		server.WriteError(w, http.StatusNotFound, "dist-tag not found")
+		return
+	}
+	delete(pkg.DistTags, r.PathValue("tag"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validVisibility(v string) bool {
+	for _, known := range registry.Visibilities {
+		if v == known {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) star(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := r.PathValue("org") + "/" + r.PathValue("name")
+	pkg, ok := s.packages[key]
+	if !ok {
+		server.WriteError(w, http.StatusNotFound, "package not found")
+		return
+	}
+	starring := r.Method == http.MethodPost
+	if starring != s.starred[key] {
+		if starring {
+			pkg.StarCount++
+		} else {
+			pkg.StarCount--
+		}
+	}
+	s.starred[key] = starring
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// version returns the published version a request names, writing a 404 if
+// there is none. The caller must hold s.mu.
+func (s *Server) version(w http.ResponseWriter, r *http.Request) *registry.Version {
+	pkg, ok := s.packages[r.PathValue("org")+"/"+r.PathValue("name")]
+	if !ok {
+		server.WriteError(w, http.StatusNotFound, "package not found")
+		return nil
+	}
+	for i := range pkg.Versions {
+		if pkg.Versions[i].Tag == r.PathValue("version") {
+			return &pkg.Versions[i]
+		}
+	}
+	server.WriteError(w, http.StatusNotFound, "version not found")
+	return nil
+}
+
+func (s *Server) deprecate(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Message string `json:"message"`
+	}
+	if r.Method == http.MethodPut {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Message == "" {
+			server.WriteError(w, http.StatusBadRequest, "message is required")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if v := s.version(w, r); v != nil {
+		v.Deprecated = body.Message
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) yank(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if v := s.version(w, r); v != nil {
+		v.Yanked = r.Method == http.MethodPut
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) listStarred(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var results []registry.Package
+	for _, p := range s.sortedPackages() {
+		if s.starred[p.FullName()] {
+			results = append(results, p)
+		}
+	}
+	server.ServeList(w, r, results)
+}
+
+func (s *Server) getManifest(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := r.PathValue("org") + "/" + r.PathValue("name")
+	data, ok := s.manifests[key+"/"+r.PathValue("version")]
+	if !ok {
+		server.WriteError(w, http.StatusNotFound, "manifest not found")
+		return
+	}
+	s.packages[key].PullCount++
+	w.Header().Set("Content-Type", "application/x-yaml")
+	w.Write(data)
+}
+
+func (s *Server) putManifest(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		server.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	org, name, version := r.PathValue("org"), r.PathValue("name"), r.PathValue("version")
+	if _, exists := s.manifests[org+"/"+name+"/"+version]; exists {
+		server.WriteError(w, http.StatusConflict, "version already exists")
+		return
+	}
+	if roles, isOrg := s.orgs[org]; isOrg && !registry.CanPublish(roles[s.profile.Username]) {
+		server.WriteError(w, http.StatusForbidden, "you need the writer role or higher to publish to "+org)
+		return
+	}
+	visibility := r.URL.Query().Get("visibility")
+	if visibility != "" && !validVisibility(visibility) {
+		server.WriteError(w, http.StatusBadRequest, "visibility must be private, org or public")
+		return
+	}
+	s.addManifest(org, name, version, data)
+	pkg := s.packages[org+"/"+name]
+	if visibility != "" {
+		pkg.Visibility = visibility
+	}
+	// Untagged pushes move latest, as the registry does
+	tag := r.URL.Query().Get("tag")
+	if tag == "" {
+		tag = registry.LatestTag
+	}
+	if pkg.DistTags == nil {
+		pkg.DistTags = map[string]string{}
+	}
+	pkg.DistTags[tag] = version
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) getProfile(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.profile
+	p.Orgs = nil
+	for _, org := range s.sortedOrgs() {
+		if role, ok := s.orgs[org][p.Username]; ok {
+			p.Orgs = append(p.Orgs, registry.Membership{Org: org, Role: role})
+		}
+	}
+	server.WriteJSON(w, http.StatusOK, p)
+}
+
+func (s *Server) listOrgs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := []registry.Org{}
+	for _, org := range s.sortedOrgs() {
+		role, ok := s.orgs[org][s.profile.Username]
+		if !ok {
+			continue
+		}
+		packages := 0
+		for _, p := range s.packages {
+			if p.Org == org {
+				packages++
+			}
+		}
+		results = append(results, registry.Org{Name: org, Role: role, MemberCount: len(s.orgs[org]), PackageCount: packages})
+	}
+	server.WriteJSON(w, http.StatusOK, map[string]interface{}{"results": results})
+}
+
+func (s *Server) createOrg(w http.ResponseWriter, r *http.Request) {
+	var org registry.Org
+	if err := json.NewDecoder(r.Body).Decode(&org); err != nil || org.Name == "" {
+		server.WriteError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, taken := s.orgs[org.Name]
+	if taken || org.Name == s.profile.Username {
+		server.WriteError(w, http.StatusConflict, "namespace already taken")
+		return
+	}
+	s.orgs[org.Name] = map[string]string{s.profile.Username: registry.RoleOwner}
+	org.Role = registry.RoleOwner
+	org.MemberCount = 1
+	server.WriteJSON(w, http.StatusCreated, org)
+}
+
+func (s *Server) listMembers(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	roles := s.orgRoles(w, r, false)
+	if roles == nil {
+		return
+	}
+	members := []registry.Member{}
+	for user, role := range roles {
+		members = append(members, registry.Member{Username: user, Email: user + "@example.com", Role: role})
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Username < members[j].Username })
+	server.WriteJSON(w, http.StatusOK, map[string]interface{}{"results": members})
+}
+
+func (s *Server) putMember(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || !slices.ContainsFunc(registry.Roles, func(role string) bool { return strings.EqualFold(role, body.Role) }) {
+		server.WriteError(w, http.StatusBadRequest, "role must be owner, admin, writer or reader")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	roles := s.orgRoles(w, r, true)
+	if roles == nil {
+		return
+	}
+	user := r.PathValue("user")
+	_, member := roles[user]
+	switch {
+	case r.Method == http.MethodPut && member:
+		server.WriteError(w, http.StatusConflict, user+" is already a member")
+		return
+	case r.Method == http.MethodPatch && !member:
+		server.WriteError(w, http.StatusNotFound, user+" is not a member")
+		return
+	}
+	roles[user] = body.Role
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteMember(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	roles := s.orgRoles(w, r, true)
+	if roles == nil {
+		return
+	}
+	user := r.PathValue("user")
+	if _, member := roles[user]; !member {
+		server.WriteError(w, http.StatusNotFound, user+" is not a member")
+		return
+	}
+	delete(roles, user)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// orgRoles returns the member roles of the org a request names, writing a
+// 404 if there is none, or a 403 unless the user is a member or, when
+// managing, an owner or admin. The caller must hold s.mu.
+func (s *Server) orgRoles(w http.ResponseWriter, r *http.Request, manage bool) map[string]string {
+	org := r.PathValue("org")
+	roles, ok := s.orgs[org]
+	if !ok {
+		server.WriteError(w, http.StatusNotFound, "org not found")
+		return nil
+	}
+	role, member := roles[s.profile.Username]
+	if !member {
+		server.WriteError(w, http.StatusForbidden, "you are not a member of "+org)
+		return nil
+	}
+	if manage && role != registry.RoleOwner && role != registry.RoleAdmin {
+		server.WriteError(w, http.StatusForbidden, "only owners and admins of "+org+" can manage its members")
+		return nil
+	}
+	return roles
+}
+
+// sortedOrgs returns org names in order.
+func (s *Server) sortedOrgs() []string {
+	names := make([]string, 0, len(s.orgs))
+	for name := range s.orgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedPackages returns copies of all packages ordered by full name.
+func (s *Server) sortedPackages() []registry.Package {
+	packages := make([]registry.Package, 0, len(s.packages))
+	for _, p := range s.packages {
+		packages = append(packages, *p)
+	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].FullName() < packages[j].FullName() })
+	return packages
+}
@@ -0,0 +1,114 @@
+package registry
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/promptbucket/cli/internal/semver"
+)
+
+// Resolve picks the published version of a package that a constraint
+// selects. An empty constraint selects the version the "latest" dist-tag
+// points at, or else the latest stable version; "@tag" selects the version a
+// dist-tag points at; a constraint that is exactly a published tag selects
+// that tag; anything else is parsed as a semver range and selects the highest
+// matching version. Tags that are not valid semver, and yanked versions, are
+// only selected by exact match or dist-tag.
+func Resolve(pkg *Package, constraint string) (Version, error) {
+	constraint = strings.TrimSpace(constraint)
+	if strings.HasPrefix(constraint, "@") {
+		return ResolveTag(pkg, constraint[1:])
+	}
+	exact := strings.TrimSpace(strings.TrimPrefix(constraint, "="))
+	for _, v := range pkg.Versions {
+		if exact != "" && v.Tag == exact {
+			return v, nil
+		}
+	}
+	if len(pkg.Versions) == 0 {
+		return Version{}, fmt.Errorf("%s has no published versions", pkg.FullName())
+	}
+	if constraint == "" {
+		if v, err := ResolveTag(pkg, LatestTag); err == nil && !v.Yanked {
+			return v, nil
+		}
+	}
+
+	byVersion := map[string]Version{}
+	var candidates []semver.Version
+	for _, v := range pkg.Versions {
+		sv, err := semver.Parse(v.Tag)
+		if err != nil || v.Yanked {
+			continue
+		}
+		byVersion[sv.String()] = v
+		candidates = append(candidates, sv)
+	}
+	if len(candidates) == 0 {
+		return Version{}, fmt.Errorf("every version of %s is yanked or not semver; pull one by exact version, e.g. %s:%s", pkg.FullName(), pkg.FullName(), pkg.Versions[0].Tag)
+	}
+
+	if constraint == "" {
+		var stable []semver.Version
+		for _, sv := range candidates {
+			if !sv.IsPrerelease() {
+				stable = append(stable, sv)
+			}
+		}
+		best, ok := semver.Max(stable)
+		if !ok {
+			return Version{}, fmt.Errorf("%s has no stable versions; specify one, e.g. %s@%s", pkg.FullName(), pkg.FullName(), pkg.Versions[0].Tag)
+		}
+		return byVersion[best.String()], nil
+	}
+
+	r, err := semver.ParseRange(constraint)
+	if err != nil {
+		return Version{}, err
+	}
+	best, ok := r.MaxSatisfying(candidates)
+	if !ok {
+		return Version{}, fmt.Errorf("no published version of %s matches %s", pkg.FullName(), constraint)
+	}
+	return byVersion[best.String()], nil
+}
+
+// LatestTag is the dist-tag that, when set, names the version pulled when
+// none is given.
+const LatestTag = "latest"
+
+// distTagPattern matches valid dist-tag names.
+var distTagPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9._-]*$`)
+
+// CheckDistTag returns an error unless name can be used as a dist-tag. Tags
+// start with a letter so they are never mistaken for versions or ranges.
+func CheckDistTag(name string) error {
+	if !distTagPattern.MatchString(name) {
+		return fmt.Errorf("invalid dist-tag %q (start with a letter; use letters, digits, '.', '_' and '-')", name)
+	}
+	return nil
+}
+
+// ResolveTag returns the published version a dist-tag points at.
+func ResolveTag(pkg *Package, tag string) (Version, error) {
+	target, ok := pkg.DistTags[tag]
+	if !ok {
+		var tags []string
+		for t := range pkg.DistTags {
+			tags = append(tags, t)
+		}
+		sort.Strings(tags)
+		if len(tags) == 0 {
+			return Version{}, fmt.Errorf("%s has no dist-tag %q (it has no dist-tags)", pkg.FullName(), tag)
+		}
+		return Version{}, fmt.Errorf("%s has no dist-tag %q (it has %s)", pkg.FullName(), tag, strings.Join(tags, ", "))
+	}
+	for _, v := range pkg.Versions {
+		if v.Tag == target {
+			return v, nil
+		}
+	}
+	return Version{}, fmt.Errorf("dist-tag %q of %s points at %s, which is not published", tag, pkg.FullName(), target)
+}
@@ -0,0 +1,134 @@
+package registry
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	pkg := &Package{
+		Org:  "acme",
+		Name: "reviewer",
+		// Newest first, as the registry lists them
+		Versions: []Version{
+			{Tag: "2.1.0-beta.1"},
+			{Tag: "2.0.0", Yanked: true},
+			{Tag: "nightly"},
+			{Tag: "1.5.0"},
+			{Tag: "1.4.0"},
+			{Tag: "1.0.0"},
+		},
+		DistTags: map[string]string{"latest": "1.4.0", "beta": "2.1.0-beta.1"},
+	}
+
+	tests := []struct {
+		constraint string
+		want       string
+		err        string
+	}{
+		{constraint: "", want: "1.4.0"},
+		{constraint: "@beta", want: "2.1.0-beta.1"},
+		{constraint: "@stable", err: `no dist-tag "stable" (it has beta, latest)`},
+		{constraint: "1.0.0", want: "1.0.0"},
+		{constraint: "=1.5.0", want: "1.5.0"},
+		{constraint: "nightly", want: "nightly"},
+		{constraint: "2.0.0", want: "2.0.0"},
+		{constraint: "^1", want: "1.5.0"},
+		{constraint: "~1.4", want: "1.4.0"},
+		{constraint: ">=2", err: "no published version of acme/reviewer matches >=2"},
+		{constraint: "^2.1.0-beta.0", want: "2.1.0-beta.1"},
+		{constraint: " ^1 ", want: "1.5.0"},
+		{constraint: "not a range", err: "invalid version range"},
+	}
+
+	for _, tt := range tests {
+		got, err := Resolve(pkg, tt.constraint)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Resolve(%q) error = %v, want one containing %q", tt.constraint, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Resolve(%q): %v", tt.constraint, err)
+			continue
+		}
+		if got.Tag != tt.want {
+			t.Errorf("Resolve(%q) = %s, want %s", tt.constraint, got.Tag, tt.want)
+		}
+	}
+}
+
+func TestResolveLatest(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions []Version
+		distTags map[string]string
+		want     string
+		err      string
+	}{
+		{
+			name:     "highest stable without a latest tag",
+			versions: []Version{{Tag: "2.0.0-rc.1"}, {Tag: "1.10.0"}, {Tag: "1.9.0"}},
+			want:     "1.10.0",
+		},
+		{
+			name:     "yanked latest tag falls back",
+			versions: []Version{{Tag: "1.1.0", Yanked: true}, {Tag: "1.0.0"}},
+			distTags: map[string]string{"latest": "1.1.0"},
+			want:     "1.0.0",
+		},
+		{
+			name:     "only pre-releases",
+			versions: []Version{{Tag: "1.0.0-beta.1"}},
+			err:      "has no stable versions",
+		},
+		{
+			name:     "everything yanked",
+			versions: []Version{{Tag: "1.0.0", Yanked: true}, {Tag: "draft"}},
+			err:      "every version of acme/reviewer is yanked or not semver",
+		},
+		{
+			name: "nothing published",
+			err:  "has no published versions",
+		},
+	}
+
+	for _, tt := range tests {
+		pkg := &Package{Org: "acme", Name: "reviewer", Versions: tt.versions, DistTags: tt.distTags}
+		got, err := Resolve(pkg, "")
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: error = %v, want one containing %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got.Tag != tt.want {
+			t.Errorf("%s: Resolve() = %s, want %s", tt.name, got.Tag, tt.want)
+		}
+	}
+}
+
+func TestCheckDistTag(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"beta", true},
+		{"next.v2", true},
+		{"release_candidate-1", true},
+		{"1.0.0", false},
+		{"^1", false},
+		{"", false},
+		{"has space", false},
+	}
+	for _, tt := range tests {
+		if err := CheckDistTag(tt.name); (err == nil) != tt.ok {
+			t.Errorf("CheckDistTag(%q) = %v, want ok = %v", tt.name, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/promptbucket/cli/internal/registry"
+)
+
+// The listing endpoints and response helpers are exported so registrytest's
+// fake registry answers them exactly as the server does.
+
+// defaultLimit is the page size when a request gives no limit.
+const defaultLimit = 20
+
+// ServeSearch answers a search request over packages, applying its query,
+// filter, sort and paging parameters.
+func ServeSearch(w http.ResponseWriter, r *http.Request, packages []registry.Package) {
+	params := r.URL.Query()
+	query := strings.ToLower(params.Get("q"))
+	results := []registry.Package{}
+	for _, p := range packages {
+		text := strings.ToLower(p.FullName() + " " + p.Description + " " + strings.Join(p.Tags, " ") + " " + strings.Join(p.Authors, " "))
+		if strings.Contains(text, query) && matchesFilters(p, params) {
+			results = append(results, p)
+		}
+	}
+
+	switch params.Get("sort") {
+	case "stars":
+		sort.SliceStable(results, func(i, j int) bool { return results[i].StarCount > results[j].StarCount })
+	case "pulls":
+		sort.SliceStable(results, func(i, j int) bool { return results[i].PullCount > results[j].PullCount })
+	case "updated":
+		sort.SliceStable(results, func(i, j int) bool { return results[i].UpdatedAt > results[j].UpdatedAt })
+	case "":
+	default:
+		WriteError(w, http.StatusBadRequest, "sort must be stars, pulls or updated")
+		return
+	}
+
+	list := paginate(r, results)
+	list.Query = params.Get("q")
+	WriteJSON(w, http.StatusOK, list)
+}
+
+// matchesFilters applies the search filter parameters to a package.
+func matchesFilters(p registry.Package, params url.Values) bool {
+	for _, tag := range params["tag"] {
+		if !containsFold(p.Tags, tag) {
+			return false
+		}
+	}
+	if a := params.Get("author"); a != "" && !containsFold(p.Authors, a) {
+		return false
+	}
+	for key, value := range map[string]string{"org": p.Org, "language": p.Language, "model_hint": p.ModelHint, "licence": p.Licence} {
+		if want := params.Get(key); want != "" && !strings.EqualFold(want, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServePopular answers a popular request, ranking packages by pulls.
+func ServePopular(w http.ResponseWriter, r *http.Request, packages []registry.Package) {
+	sort.SliceStable(packages, func(i, j int) bool { return packages[i].PullCount > packages[j].PullCount })
+	ServeList(w, r, packages)
+}
+
+// ServeTrending answers a trending request, ranking the packages updated
+// since the "since" parameter, a week ago by default, by stars.
+func ServeTrending(w http.ResponseWriter, r *http.Request, packages []registry.Package) {
+	since := time.Now().AddDate(0, 0, -7)
+	if v := r.URL.Query().Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "since must be an RFC 3339 time")
+			return
+		}
+		since = t
+	}
+
+	results := []registry.Package{}
+	for _, p := range packages {
+		if updated, ok := p.Updated(); ok && !updated.Before(since) {
+			results = append(results, p)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].StarCount > results[j].StarCount })
+	ServeList(w, r, results)
+}
+
+// ServeList answers with the page of packages the request's limit and
+// offset parameters select.
+func ServeList(w http.ResponseWriter, r *http.Request, packages []registry.Package) {
+	WriteJSON(w, http.StatusOK, paginate(r, packages))
+}
+
+// paginate applies a request's limit and offset parameters to results.
+func paginate(r *http.Request, results []registry.Package) registry.PackageList {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultLimit
+	}
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	list := registry.PackageList{Results: []registry.Package{}, Total: len(results), Limit: limit, Offset: offset}
+	if offset < len(results) {
+		end := min(offset+limit, len(results))
+		list.Results = results[offset:end]
+	}
+	return list
+}
+
+// WriteJSON writes v as the response body with status.
+func WriteJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// WriteError writes an error response in the registry's format.
+func WriteError(w http.ResponseWriter, status int, message string) {
+	WriteJSON(w, status, map[string]string{"message": message})
+}
@@ -0,0 +1,316 @@
+// Package server serves the registry API from a directory, so a private
+// registry can run without the hosted service. It implements the endpoints
+// the CLI uses to search, pull, push and star packages.
+package server
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/promptbucket/cli/internal/registry"
+	"github.com/promptbucket/cli/internal/semver"
+)
+
+// maxManifestSize bounds the size of a pushed manifest.
+const maxManifestSize = 1 << 20
+
+// Server serves a Store over HTTP. Anyone may read public packages; pushing,
+// starring and reading private packages need a user's token.
+type Server struct {
+	store *Store
+	users []User
+	mux   *http.ServeMux
+
+	// mu serializes changes to the store
+	mu sync.Mutex
+}
+
+// New returns a server for store that authenticates users by token.
+func New(store *Store, users []User) *Server {
+	s := &Server{store: store, users: users, mux: http.NewServeMux()}
+
+	s.mux.HandleFunc("GET /healthz", s.healthz)
+	s.mux.HandleFunc("GET /v1/healthz", s.healthz)
+	s.mux.HandleFunc("GET /v1/packages/search", s.search)
+	s.mux.HandleFunc("GET /v1/packages/popular", s.popular)
+	s.mux.HandleFunc("GET /v1/packages/trending", s.trending)
+	s.mux.HandleFunc("GET /v1/packages/{org}/{name}", s.getPackage)
+	s.mux.HandleFunc("POST /v1/packages/{org}/{name}/star", s.star)
+	s.mux.HandleFunc("DELETE /v1/packages/{org}/{name}/star", s.star)
+	s.mux.HandleFunc("GET /v1/user/starred", s.listStarred)
+	s.mux.HandleFunc("GET /v1/user/profile", s.getProfile)
+	s.mux.HandleFunc("GET /v1/manifests/{org}/{name}/{version}", s.getManifest)
+	s.mux.HandleFunc("PUT /v1/manifests/{org}/{name}/{version}", s.putManifest)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// user returns the user whose token a request carries, or nil for an
+// anonymous request. It writes a 401 and returns false for an unknown token.
+func (s *Server) user(w http.ResponseWriter, r *http.Request) (*User, bool) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return nil, true
+	}
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if u := userByToken(s.users, token); ok && u != nil {
+		return u, true
+	}
+	WriteError(w, http.StatusUnauthorized, "invalid token")
+	return nil, false
+}
+
+// requireUser is like user, but also writes a 401 for anonymous requests.
+func (s *Server) requireUser(w http.ResponseWriter, r *http.Request) *User {
+	u, ok := s.user(w, r)
+	if ok && u == nil {
+		WriteError(w, http.StatusUnauthorized, "authentication required")
+	}
+	return u
+}
+
+// canRead reports whether u may see pkg: public packages are visible to
+// everyone, others only to members of their namespace.
+func canRead(u *User, pkg *registry.Package) bool {
+	if pkg.Visibility == "" || pkg.Visibility == registry.VisibilityPublic {
+		return true
+	}
+	return u != nil && u.Role(pkg.Org) != ""
+}
+
+// visiblePackages returns the packages u may see, ordered by full name.
+func (s *Server) visiblePackages(u *User) ([]registry.Package, error) {
+	all, err := s.store.Packages()
+	if err != nil {
+		return nil, err
+	}
+	packages := []registry.Package{}
+	for i := range all {
+		if canRead(u, &all[i]) {
+			packages = append(packages, all[i])
+		}
+	}
+	return packages, nil
+}
+
+// readablePackage returns the package a request names, writing a 404 if it
+// does not exist or u may not see it.
+func (s *Server) readablePackage(w http.ResponseWriter, r *http.Request, u *User) *registry.Package {
+	pkg, err := s.store.Package(r.PathValue("org"), r.PathValue("name"))
+	if err == nil && !canRead(u, pkg) {
+		err = ErrNotFound
+	}
+	if errors.Is(err, ErrNotFound) {
+		WriteError(w, http.StatusNotFound, "package not found")
+		return nil
+	}
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err.Error())
+		return nil
+	}
+	return pkg
+}
+
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	if packages, ok := s.listable(w, r); ok {
+		ServeSearch(w, r, packages)
+	}
+}
+
+func (s *Server) popular(w http.ResponseWriter, r *http.Request) {
+	if packages, ok := s.listable(w, r); ok {
+		ServePopular(w, r, packages)
+	}
+}
+
+func (s *Server) trending(w http.ResponseWriter, r *http.Request) {
+	if packages, ok := s.listable(w, r); ok {
+		ServeTrending(w, r, packages)
+	}
+}
+
+// listable returns the packages the requesting user may list.
+func (s *Server) listable(w http.ResponseWriter, r *http.Request) ([]registry.Package, bool) {
+	u, ok := s.user(w, r)
+	if !ok {
+		return nil, false
+	}
+	packages, err := s.visiblePackages(u)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+	return packages, true
+}
+
+func (s *Server) getPackage(w http.ResponseWriter, r *http.Request) {
+	u, ok := s.user(w, r)
+	if !ok {
+		return
+	}
+	pkg := s.readablePackage(w, r, u)
+	if pkg == nil {
+		return
+	}
+	if u != nil {
+		starred, err := s.store.Starred(u.Username)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		pkg.HasStarred = starred[pkg.FullName()]
+	}
+	WriteJSON(w, http.StatusOK, pkg)
+}
+
+func (s *Server) star(w http.ResponseWriter, r *http.Request) {
+	u := s.requireUser(w, r)
+	if u == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pkg := s.readablePackage(w, r, u)
+	if pkg == nil {
+		return
+	}
+	if err := s.store.SetStar(u.Username, pkg, r.Method == http.MethodPost); err != nil {
+		WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listStarred(w http.ResponseWriter, r *http.Request) {
+	u := s.requireUser(w, r)
+	if u == nil {
+		return
+	}
+	starred, err := s.store.Starred(u.Username)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	packages, err := s.visiblePackages(u)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	results := []registry.Package{}
+	for _, p := range packages {
+		if starred[p.FullName()] {
+			p.HasStarred = true
+			results = append(results, p)
+		}
+	}
+	ServeList(w, r, results)
+}
+
+func (s *Server) getProfile(w http.ResponseWriter, r *http.Request) {
+	u := s.requireUser(w, r)
+	if u == nil {
+		return
+	}
+	profile := registry.Profile{Username: u.Username, Email: u.Email}
+	for org, role := range u.Orgs {
+		profile.Orgs = append(profile.Orgs, registry.Membership{Org: org, Role: role})
+	}
+	sort.Slice(profile.Orgs, func(i, j int) bool { return profile.Orgs[i].Org < profile.Orgs[j].Org })
+	WriteJSON(w, http.StatusOK, profile)
+}
+
+func (s *Server) getManifest(w http.ResponseWriter, r *http.Request) {
+	u, ok := s.user(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pkg := s.readablePackage(w, r, u)
+	if pkg == nil {
+		return
+	}
+	data, err := s.store.Manifest(pkg.Org, pkg.Name, r.PathValue("version"))
+	if errors.Is(err, ErrNotFound) {
+		WriteError(w, http.StatusNotFound, "manifest not found")
+		return
+	}
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// A failed count does not fail the download
+	pkg.PullCount++
+	s.store.SavePackage(pkg)
+
+	w.Header().Set("Content-Type", "application/x-yaml")
+	w.Write(data)
+}
+
+func (s *Server) putManifest(w http.ResponseWriter, r *http.Request) {
+	u := s.requireUser(w, r)
+	if u == nil {
+		return
+	}
+
+	org, name, version := r.PathValue("org"), r.PathValue("name"), r.PathValue("version")
+	if !namePattern.MatchString(org) || !namePattern.MatchString(name) {
+		WriteError(w, http.StatusBadRequest, "invalid package name")
+		return
+	}
+	if _, err := semver.Parse(version); err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !registry.CanPublish(u.Role(org)) {
+		WriteError(w, http.StatusForbidden, "you need the writer role or higher to publish to "+org)
+		return
+	}
+	visibility := r.URL.Query().Get("visibility")
+	if visibility != "" && !containsFold(registry.Visibilities, visibility) {
+		WriteError(w, http.StatusBadRequest, "visibility must be private, org or public")
+		return
+	}
+	tag := r.URL.Query().Get("tag")
+	if tag != "" {
+		if err := registry.CheckDistTag(tag); err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxManifestSize))
+	if err != nil {
+		WriteError(w, http.StatusRequestEntityTooLarge, "manifest is larger than "+strconv.Itoa(maxManifestSize>>10)+" KiB")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pkg, err := s.store.Publish(PublishRequest{Org: org, Name: name, Version: version, Manifest: data, Visibility: strings.ToLower(visibility), Tag: tag})
+	switch {
+	case errors.Is(err, ErrExists):
+		WriteError(w, http.StatusConflict, "version "+version+" of "+org+"/"+name+" already exists")
+	case err != nil:
+		WriteError(w, http.StatusBadRequest, err.Error())
+	default:
+		WriteJSON(w, http.StatusCreated, pkg)
+	}
+}
@@ -0,0 +1,337 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/promptbucket/cli/internal/registry"
+)
+
+const (
+	aliceToken = "alice-token-0123456789"
+	bobToken   = "bob-token-0123456789"
+)
+
+// newTestServer serves an empty store for alice, who writes to acme, and
+// bob, who belongs to no org.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	users := []User{
+		{Username: "alice", Token: aliceToken, Orgs: map[string]string{"acme": registry.RoleWriter}},
+		{Username: "bob", Token: bobToken},
+	}
+	server := httptest.NewServer(New(&Store{Root: t.TempDir()}, users))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// call sends a request with token, if any, and returns the status and body.
+func call(t *testing.T, server *httptest.Server, method, path, token, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(data)
+}
+
+// push publishes a minimal manifest for org/name at version.
+func push(t *testing.T, server *httptest.Server, org, name, version, query, token string) (int, string) {
+	t.Helper()
+	manifest := "name: " + name + "\nversion: " + version + "\nprompt: Hello\n"
+	path := "/v1/manifests/" + org + "/" + name + "/" + version
+	if query != "" {
+		path += "?" + query
+	}
+	return call(t, server, "PUT", path, token, manifest)
+}
+
+// getPackage returns org/name as token sees it.
+func getPackage(t *testing.T, server *httptest.Server, org, name, token string) registry.Package {
+	t.Helper()
+	status, body := call(t, server, "GET", "/v1/packages/"+org+"/"+name, token, "")
+	if status != http.StatusOK {
+		t.Fatalf("GET %s/%s = %d %s", org, name, status, body)
+	}
+	var pkg registry.Package
+	if err := json.Unmarshal([]byte(body), &pkg); err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
+
+func TestAuthentication(t *testing.T) {
+	server := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{"push without a token", "PUT", "/v1/manifests/alice/demo/1.0.0", "", http.StatusUnauthorized},
+		{"push with a wrong token", "PUT", "/v1/manifests/alice/demo/1.0.0", "not-a-real-token", http.StatusUnauthorized},
+		{"star without a token", "POST", "/v1/packages/alice/demo/star", "", http.StatusUnauthorized},
+		{"profile without a token", "GET", "/v1/user/profile", "", http.StatusUnauthorized},
+		{"starred without a token", "GET", "/v1/user/starred", "", http.StatusUnauthorized},
+		{"search with a wrong token", "GET", "/v1/packages/search?q=demo", "not-a-real-token", http.StatusUnauthorized},
+		{"anonymous search", "GET", "/v1/packages/search?q=demo", "", http.StatusOK},
+		{"profile with a token", "GET", "/v1/user/profile", aliceToken, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		if status, body := call(t, server, tt.method, tt.path, tt.token, "name: demo\nversion: 1.0.0\n"); status != tt.want {
+			t.Errorf("%s: status = %d %s, want %d", tt.name, status, body, tt.want)
+		}
+	}
+}
+
+func TestPushPull(t *testing.T) {
+	server := newTestServer(t)
+
+	if status, body := push(t, server, "acme", "demo", "1.0.0", "", aliceToken); status != http.StatusCreated {
+		t.Fatalf("push = %d %s", status, body)
+	}
+	status, body := call(t, server, "GET", "/v1/manifests/acme/demo/1.0.0", "", "")
+	if status != http.StatusOK || body != "name: demo\nversion: 1.0.0\nprompt: Hello\n" {
+		t.Errorf("pull = %d %q", status, body)
+	}
+
+	pkg := getPackage(t, server, "acme", "demo", "")
+	if len(pkg.Versions) != 1 || pkg.Versions[0].Tag != "1.0.0" || !strings.HasPrefix(pkg.Versions[0].Digest, "sha256:") {
+		t.Errorf("versions = %+v, want 1.0.0 with a digest", pkg.Versions)
+	}
+	if pkg.PullCount != 1 {
+		t.Errorf("pull count = %d, want 1", pkg.PullCount)
+	}
+
+	if status, _ := call(t, server, "GET", "/v1/manifests/acme/demo/2.0.0", "", ""); status != http.StatusNotFound {
+		t.Errorf("pull of a missing version = %d, want 404", status)
+	}
+}
+
+func TestPushDuplicateVersion(t *testing.T) {
+	server := newTestServer(t)
+
+	if status, body := push(t, server, "alice", "demo", "1.0.0", "", aliceToken); status != http.StatusCreated {
+		t.Fatalf("push = %d %s", status, body)
+	}
+	status, body := push(t, server, "alice", "demo", "1.0.0", "", aliceToken)
+	if status != http.StatusConflict || !strings.Contains(body, "already exists") {
+		t.Errorf("second push = %d %s, want 409", status, body)
+	}
+}
+
+func TestPushPermissions(t *testing.T) {
+	server := newTestServer(t)
+
+	tests := []struct {
+		name  string
+		org   string
+		token string
+		want  int
+	}{
+		{"own namespace", "bob", bobToken, http.StatusCreated},
+		{"writer in org", "acme", aliceToken, http.StatusCreated},
+		{"not a member", "acme", bobToken, http.StatusForbidden},
+		{"another user's namespace", "alice", bobToken, http.StatusForbidden},
+	}
+
+	for i, tt := range tests {
+		if status, body := push(t, server, tt.org, "demo"+strconv.Itoa(i), "1.0.0", "", tt.token); status != tt.want {
+			t.Errorf("%s: status = %d %s, want %d", tt.name, status, body, tt.want)
+		}
+	}
+}
+
+func TestPushValidation(t *testing.T) {
+	server := newTestServer(t)
+
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"org with a slash", "/v1/manifests/al%2Fice/demo/1.0.0", http.StatusBadRequest},
+		{"name starting with a dot", "/v1/manifests/alice/.demo/1.0.0", http.StatusBadRequest},
+		{"name with a space", "/v1/manifests/alice/de%20mo/1.0.0", http.StatusBadRequest},
+		{"version that is a path", "/v1/manifests/alice/demo/..%2F..%2Fusers", http.StatusBadRequest},
+		{"partial version", "/v1/manifests/alice/demo/1.0", http.StatusBadRequest},
+		{"version with a prefix", "/v1/manifests/alice/demo/v1.0.0", http.StatusBadRequest},
+		{"invalid visibility", "/v1/manifests/alice/demo/1.0.0?visibility=secret", http.StatusBadRequest},
+		{"invalid dist-tag", "/v1/manifests/alice/demo/1.0.0?tag=1.0", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		if status, body := call(t, server, "PUT", tt.path, aliceToken, "name: demo\nversion: 1.0.0\n"); status != tt.want {
+			t.Errorf("%s: status = %d %s, want %d", tt.name, status, body, tt.want)
+		}
+	}
+
+	// The manifest must match the name and version it is pushed as
+	status, body := call(t, server, "PUT", "/v1/manifests/alice/demo/1.0.0", aliceToken, "name: other\nversion: 1.0.0\n")
+	if status != http.StatusBadRequest || !strings.Contains(body, "was pushed as") {
+		t.Errorf("mismatched manifest = %d %s, want 400", status, body)
+	}
+}
+
+func TestPrivateVisibility(t *testing.T) {
+	server := newTestServer(t)
+
+	if status, body := push(t, server, "acme", "secret", "1.0.0", "visibility=private", aliceToken); status != http.StatusCreated {
+		t.Fatalf("push = %d %s", status, body)
+	}
+	if status, body := push(t, server, "acme", "open", "1.0.0", "", aliceToken); status != http.StatusCreated {
+		t.Fatalf("push = %d %s", status, body)
+	}
+
+	for _, tt := range []struct {
+		who   string
+		token string
+		sees  bool
+	}{
+		{"anonymous", "", false},
+		{"non-member", bobToken, false},
+		{"member", aliceToken, true},
+	} {
+		want := http.StatusNotFound
+		if tt.sees {
+			want = http.StatusOK
+		}
+		if status, _ := call(t, server, "GET", "/v1/packages/acme/secret", tt.token, ""); status != want {
+			t.Errorf("%s: package = %d, want %d", tt.who, status, want)
+		}
+		if status, _ := call(t, server, "GET", "/v1/manifests/acme/secret/1.0.0", tt.token, ""); status != want {
+			t.Errorf("%s: manifest = %d, want %d", tt.who, status, want)
+		}
+		if tt.token != "" {
+			want := http.StatusNotFound
+			if tt.sees {
+				want = http.StatusNoContent
+			}
+			if status, _ := call(t, server, "POST", "/v1/packages/acme/secret/star", tt.token, ""); status != want {
+				t.Errorf("%s: star = %d, want %d", tt.who, status, want)
+			}
+		}
+
+		_, body := call(t, server, "GET", "/v1/packages/search?q=acme", tt.token, "")
+		var list registry.PackageList
+		if err := json.Unmarshal([]byte(body), &list); err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, p := range list.Results {
+			names = append(names, p.FullName())
+		}
+		if got := strings.Contains(strings.Join(names, " "), "acme/secret"); got != tt.sees || list.Total != len(names) {
+			t.Errorf("%s: search = %v (total %d)", tt.who, names, list.Total)
+		}
+	}
+}
+
+func TestStars(t *testing.T) {
+	server := newTestServer(t)
+	if status, body := push(t, server, "alice", "demo", "1.0.0", "", aliceToken); status != http.StatusCreated {
+		t.Fatalf("push = %d %s", status, body)
+	}
+
+	star := func(method, token string) {
+		t.Helper()
+		if status, body := call(t, server, method, "/v1/packages/alice/demo/star", token, ""); status != http.StatusNoContent {
+			t.Fatalf("%s star = %d %s", method, status, body)
+		}
+	}
+
+	// Starring twice counts once
+	star("POST", aliceToken)
+	star("POST", aliceToken)
+	star("POST", bobToken)
+	if pkg := getPackage(t, server, "alice", "demo", aliceToken); pkg.StarCount != 2 || !pkg.HasStarred {
+		t.Errorf("after starring: count = %d, has_starred = %v; want 2, true", pkg.StarCount, pkg.HasStarred)
+	}
+
+	_, body := call(t, server, "GET", "/v1/user/starred", bobToken, "")
+	var list registry.PackageList
+	if err := json.Unmarshal([]byte(body), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Results) != 1 || list.Results[0].FullName() != "alice/demo" {
+		t.Errorf("starred = %+v, want alice/demo", list.Results)
+	}
+
+	star("DELETE", bobToken)
+	star("DELETE", bobToken)
+	if pkg := getPackage(t, server, "alice", "demo", bobToken); pkg.StarCount != 1 || pkg.HasStarred {
+		t.Errorf("after unstarring: count = %d, has_starred = %v; want 1, false", pkg.StarCount, pkg.HasStarred)
+	}
+
+	if status, _ := call(t, server, "POST", "/v1/packages/alice/missing/star", aliceToken, ""); status != http.StatusNotFound {
+		t.Errorf("star of a missing package = %d, want 404", status)
+	}
+}
+
+func TestPublishDistTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions []string
+		tags     []string
+		want     map[string]string
+	}{
+		{"stable moves latest", []string{"1.0.0", "1.1.0"}, []string{"", ""}, map[string]string{"latest": "1.1.0"}},
+		{"pre-release leaves latest", []string{"1.0.0", "2.0.0-beta.1"}, []string{"", ""}, map[string]string{"latest": "1.0.0"}},
+		{"only pre-releases", []string{"1.0.0-rc.1"}, []string{""}, map[string]string{}},
+		{"tagged pre-release", []string{"1.0.0", "2.0.0-beta.1"}, []string{"", "beta"}, map[string]string{"latest": "1.0.0", "beta": "2.0.0-beta.1"}},
+		{"tagged stable leaves latest", []string{"1.0.0", "1.1.0"}, []string{"", "canary"}, map[string]string{"latest": "1.0.0", "canary": "1.1.0"}},
+		{"pre-release tagged latest", []string{"1.0.0", "2.0.0-rc.1"}, []string{"", "latest"}, map[string]string{"latest": "2.0.0-rc.1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &Store{Root: t.TempDir()}
+			var pkg *registry.Package
+			for i, version := range tt.versions {
+				var err error
+				pkg, err = store.Publish(PublishRequest{
+					Org:      "acme",
+					Name:     "demo",
+					Version:  version,
+					Manifest: []byte("name: demo\nversion: " + version + "\n"),
+					Tag:      tt.tags[i],
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			got := pkg.DistTags
+			if got == nil {
+				got = map[string]string{}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("dist-tags = %v, want %v", got, tt.want)
+			}
+			for tag, version := range tt.want {
+				if got[tag] != version {
+					t.Errorf("dist-tags = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/promptbucket/cli/internal/atomicfile"
+	"github.com/promptbucket/cli/internal/registry"
+	"github.com/promptbucket/cli/internal/semver"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNotFound is returned for packages and versions that do not exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrExists is returned when publishing a version that already exists.
+var ErrExists = errors.New("version already exists")
+
+// namePattern matches org, package and user names that are safe to use as
+// directory names.
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Store keeps packages in a directory:
+//
+//	packages/<org>/<name>/package.json         metadata, versions and dist-tags
+//	packages/<org>/<name>/<version>.yaml       published manifests
+//	stars/<user>.json                          packages a user has starred
+//
+// Callers serialize writes.
+type Store struct {
+	Root string
+}
+
+// packageDir returns the directory of org/name.
+func (s *Store) packageDir(org, name string) string {
+	return filepath.Join(s.Root, "packages", org, name)
+}
+
+// Package returns the metadata of org/name.
+func (s *Store) Package(org, name string) (*registry.Package, error) {
+	if !namePattern.MatchString(org) || !namePattern.MatchString(name) {
+		return nil, ErrNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(s.packageDir(org, name), "package.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pkg registry.Package
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s/%s: %w", org, name, err)
+	}
+	return &pkg, nil
+}
+
+// Packages returns every package ordered by full name.
+func (s *Store) Packages() ([]registry.Package, error) {
+	paths, err := filepath.Glob(filepath.Join(s.Root, "packages", "*", "*", "package.json"))
+	if err != nil {
+		return nil, err
+	}
+	packages := make([]registry.Package, 0, len(paths))
+	for _, path := range paths {
+		dir := filepath.Dir(path)
+		pkg, err := s.Package(filepath.Base(filepath.Dir(dir)), filepath.Base(dir))
+		if err != nil {
+			return nil, err
+		}
+		packages = append(packages, *pkg)
+	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].FullName() < packages[j].FullName() })
+	return packages, nil
+}
+
+// SavePackage writes the metadata of a package.
+func (s *Store) SavePackage(pkg *registry.Package) error {
+	data, err := json.MarshalIndent(pkg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(filepath.Join(s.packageDir(pkg.Org, pkg.Name), "package.json"), append(data, '\n'), 0644)
+}
+
+// Manifest returns a published manifest.
+func (s *Store) Manifest(org, name, version string) ([]byte, error) {
+	if !namePattern.MatchString(org) || !namePattern.MatchString(name) {
+		return nil, ErrNotFound
+	}
+	if _, err := semver.Parse(version); err != nil {
+		return nil, ErrNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(s.packageDir(org, name), version+".yaml"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// PublishRequest is a manifest to add as a new version.
+type PublishRequest struct {
+	Org      string
+	Name     string
+	Version  string
+	Manifest []byte
+	// Visibility changes the package's visibility when set; new packages
+	// are public by default
+	Visibility string
+	// Tag is the dist-tag to point at the version; it defaults to latest
+	// for stable versions and to none for pre-releases
+	Tag string
+}
+
+// Publish stores a new version, creating the package on first publish and
+// updating its description from the manifest.
+func (s *Store) Publish(p PublishRequest) (*registry.Package, error) {
+	var meta struct {
+		Name        string   `yaml:"name"`
+		Version     string   `yaml:"version"`
+		Description string   `yaml:"description"`
+		Authors     []string `yaml:"authors"`
+		Tags        []string `yaml:"tags"`
+		Language    string   `yaml:"language"`
+		ModelHint   string   `yaml:"model_hint"`
+		Licence     string   `yaml:"licence"`
+	}
+	if err := yaml.Unmarshal(p.Manifest, &meta); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if meta.Name != p.Name || meta.Version != p.Version {
+		return nil, fmt.Errorf("manifest is %s@%s but was pushed as %s@%s", meta.Name, meta.Version, p.Name, p.Version)
+	}
+
+	pkg, err := s.Package(p.Org, p.Name)
+	now := time.Now().UTC().Format(time.RFC3339)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		pkg = &registry.Package{
+			ID:         p.Org + "/" + p.Name,
+			Org:        p.Org,
+			Name:       p.Name,
+			Visibility: registry.VisibilityPublic,
+			CreatedAt:  now,
+		}
+	case err != nil:
+		return nil, err
+	}
+	for _, v := range pkg.Versions {
+		if v.Tag == p.Version {
+			return nil, ErrExists
+		}
+	}
+
+	if err := atomicfile.Write(filepath.Join(s.packageDir(p.Org, p.Name), p.Version+".yaml"), p.Manifest, 0644); err != nil {
+		return nil, err
+	}
+
+	// Newest first, as the registry lists them
+	sum := sha256.Sum256(p.Manifest)
+	v := registry.Version{Tag: p.Version, Digest: "sha256:" + hex.EncodeToString(sum[:]), CreatedAt: now}
+	pkg.Versions = append([]registry.Version{v}, pkg.Versions...)
+	pkg.Description = meta.Description
+	pkg.Authors = meta.Authors
+	pkg.Tags = meta.Tags
+	pkg.Language = meta.Language
+	pkg.ModelHint = meta.ModelHint
+	pkg.Licence = meta.Licence
+	pkg.UpdatedAt = now
+	if p.Visibility != "" {
+		pkg.Visibility = p.Visibility
+	}
+	// Untagged pre-releases get no dist-tag, so latest stays on a release
+	tag := p.Tag
+	if sv, err := semver.Parse(p.Version); tag == "" && err == nil && !sv.IsPrerelease() {
+		tag = registry.LatestTag
+	}
+	if tag != "" {
+		if pkg.DistTags == nil {
+			pkg.DistTags = map[string]string{}
+		}
+		pkg.DistTags[tag] = p.Version
+	}
+
+	if err := s.SavePackage(pkg); err != nil {
+		return nil, err
+	}
+	return pkg, nil
+}
+
+// starsPath returns the file listing a user's starred packages.
+func (s *Store) starsPath(username string) string {
+	return filepath.Join(s.Root, "stars", username+".json")
+}
+
+// Starred returns the full names of the packages a user has starred.
+func (s *Store) Starred(username string) (map[string]bool, error) {
+	starred := map[string]bool{}
+	data, err := os.ReadFile(s.starsPath(username))
+	if errors.Is(err, os.ErrNotExist) {
+		return starred, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, fmt.Errorf("failed to parse stars of %s: %w", username, err)
+	}
+	for _, name := range names {
+		starred[name] = true
+	}
+	return starred, nil
+}
+
+// SetStar stars or unstars a package for a user, keeping the package's star
+// count in step.
+func (s *Store) SetStar(username string, pkg *registry.Package, star bool) error {
+	starred, err := s.Starred(username)
+	if err != nil {
+		return err
+	}
+	if starred[pkg.FullName()] == star {
+		return nil
+	}
+	if star {
+		starred[pkg.FullName()] = true
+		pkg.StarCount++
+	} else {
+		delete(starred, pkg.FullName())
+		pkg.StarCount--
+	}
+
+	names := make([]string, 0, len(starred))
+	for name := range starred {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	data, err := json.MarshalIndent(names, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := atomicfile.Write(s.starsPath(username), append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return s.SavePackage(pkg)
+}
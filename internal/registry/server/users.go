@@ -0,0 +1,96 @@
+package server
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/promptbucket/cli/internal/registry"
+	"gopkg.in/yaml.v3"
+)
+
+// UsersFile lists the users allowed to publish and star, in the root
+// directory:
+//
+//	users:
+//	  - username: alice
+//	    email: alice@example.com
+//	    token: <a long random string>
+//	    orgs: {acme: writer}
+//
+// Clients send the token as "Authorization: Bearer <token>".
+const UsersFile = "users.yaml"
+
+// User is a user of the server.
+type User struct {
+	Username string `yaml:"username"`
+	Email    string `yaml:"email"`
+	Token    string `yaml:"token"`
+	// Orgs maps the orgs the user belongs to to their role in each.
+	Orgs map[string]string `yaml:"orgs"`
+}
+
+// Role returns the user's role in an org: owner of their own namespace, or
+// empty if they are not a member.
+func (u *User) Role(org string) string {
+	if org == u.Username {
+		return registry.RoleOwner
+	}
+	return u.Orgs[org]
+}
+
+// LoadUsers reads a users file. A missing file has no users.
+func LoadUsers(path string) ([]User, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var file struct {
+		Users []User `yaml:"users"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	seen := map[string]bool{}
+	for _, u := range file.Users {
+		switch {
+		case !namePattern.MatchString(u.Username):
+			return nil, fmt.Errorf("%s: invalid username %q", path, u.Username)
+		case seen[u.Username]:
+			return nil, fmt.Errorf("%s: user %s is listed more than once", path, u.Username)
+		case len(u.Token) < 16:
+			return nil, fmt.Errorf("%s: the token of %s must be at least 16 characters", path, u.Username)
+		}
+		seen[u.Username] = true
+		for org, role := range u.Orgs {
+			if !validRole(role) {
+				return nil, fmt.Errorf("%s: invalid role %q for %s in %s", path, role, u.Username, org)
+			}
+		}
+	}
+	return file.Users, nil
+}
+
+func validRole(role string) bool {
+	for _, r := range registry.Roles {
+		if role == r {
+			return true
+		}
+	}
+	return false
+}
+
+// userByToken returns the user a token belongs to, or nil.
+func userByToken(users []User, token string) *User {
+	for i := range users {
+		if subtle.ConstantTimeCompare([]byte(users[i].Token), []byte(token)) == 1 {
+			return &users[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package registry
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Package is a package as described by the registry.
+type Package struct {
+	ID          string   `json:"id,omitempty"`
+	Name        string   `json:"name"`
+	Org         string   `json:"org,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Authors     []string `json:"authors,omitempty"`
+	Language    string   `json:"language,omitempty"`
+	ModelHint   string   `json:"model_hint,omitempty"`
+	Licence     string   `json:"licence,omitempty"`
+	StarCount   int      `json:"star_count"`
+	PullCount   int      `json:"pull_count"`
+	HasStarred  bool     `json:"has_starred,omitempty"`
+	// Visibility is one of Visibilities; empty if the registry did not say.
+	Visibility string    `json:"visibility,omitempty"`
+	Versions   []Version `json:"versions,omitempty"`
+	// DistTags maps distribution tags such as "beta" or "stable" to the
+	// version each points at.
+	DistTags  map[string]string `json:"dist_tags,omitempty"`
+	CreatedAt string            `json:"created_at,omitempty"`
+	UpdatedAt string            `json:"updated_at,omitempty"`
+}
+
+// UnmarshalJSON accepts the owning organization under any of the names
+// registry endpoints use for it: "org", "org_name" or "org_id".
+func (p *Package) UnmarshalJSON(data []byte) error {
+	type plain Package
+	aux := struct {
+		*plain
+		OrgName string `json:"org_name"`
+		OrgID   string `json:"org_id"`
+	}{plain: (*plain)(p)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if p.Org == "" {
+		p.Org = aux.OrgName
+	}
+	if p.Org == "" {
+		p.Org = aux.OrgID
+	}
+	return nil
+}
+
+// FullName returns "org/name", or just the name when the org is unknown.
+func (p Package) FullName() string {
+	if p.Org == "" {
+		return p.Name
+	}
+	return p.Org + "/" + p.Name
+}
+
+// Created returns when the package was first published, if known.
+func (p Package) Created() (time.Time, bool) {
+	return parseTime(p.CreatedAt)
+}
+
+// Updated returns when the package last changed, if known.
+func (p Package) Updated() (time.Time, bool) {
+	return parseTime(p.UpdatedAt)
+}
+
+// Version is one published version of a package.
+type Version struct {
+	Tag       string `json:"tag"`
+	Digest    string `json:"digest,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+	// Deprecated is the publisher's reason for deprecating the version,
+	// empty if it is not deprecated.
+	Deprecated string `json:"deprecated,omitempty"`
+	// Yanked versions stay downloadable but are skipped by range resolution.
+	Yanked bool `json:"yanked,omitempty"`
+}
+
+// Published returns when the version was published, if known.
+func (v Version) Published() (time.Time, bool) {
+	return parseTime(v.CreatedAt)
+}
+
+// ListOptions selects a page of a listing.
+type ListOptions struct {
+	// Limit is the page size; zero uses the registry default.
+	Limit int
+	// Offset is the number of results to skip.
+	Offset int
+}
+
+// SearchRequest selects packages matching a query and filters. Every
+// filter given must match.
+type SearchRequest struct {
+	// Query matches names, descriptions, tags and authors. It may be empty
+	// when filters are given.
+	Query string
+	// Tags requires each of these tags.
+	Tags      []string
+	Author    string
+	Org       string
+	Language  string
+	ModelHint string
+	Licence   string
+	// Sort orders results by one of SortOrders; empty means relevance.
+	Sort string
+	ListOptions
+}
+
+// SortOrders are the accepted SearchRequest.Sort values.
+var SortOrders = []string{"stars", "pulls", "updated"}
+
+// TrendingRequest selects packages gaining stars and pulls.
+type TrendingRequest struct {
+	// Since limits the trend window to activity after this time; zero uses
+	// the registry default.
+	Since time.Time
+	ListOptions
+}
+
+// PackageList is one page of a package listing.
+type PackageList struct {
+	// Query is the search query, for search results.
+	Query   string    `json:"query,omitempty"`
+	Results []Package `json:"results"`
+	// Total counts results across all pages.
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// Package visibilities: who can see and pull a package.
+const (
+	// VisibilityPrivate packages are visible only to their owner.
+	VisibilityPrivate = "private"
+	// VisibilityOrg packages are visible to members of the owning org.
+	VisibilityOrg = "org"
+	// VisibilityPublic packages are visible to everyone.
+	VisibilityPublic = "public"
+)
+
+// Visibilities are the accepted package visibilities, most restricted first.
+var Visibilities = []string{VisibilityPrivate, VisibilityOrg, VisibilityPublic}
+
+// PushRequest uploads a manifest as a new package version.
+type PushRequest struct {
+	Org      string
+	Name     string
+	Version  string
+	Manifest []byte
+	// Tag is an optional distribution tag for the version, e.g. "beta".
+	Tag string
+	// Visibility sets the package's visibility; empty keeps the current
+	// one, or the registry default for a new package.
+	Visibility string
+}
+
+func parseTime(s string) (time.Time, bool) {
+	if s == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	return t, err == nil
+}
@@ -0,0 +1,297 @@
+// Package schema validates manifests against the embedded manifest JSON Schema.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/promptbucket/cli/spec"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// schemaURL is the resource name the embedded schema is registered under.
+// It matches the schema's own $id so relative references keep working.
+const schemaURL = "https://promptbucket.io/schemas/manifest.schema.yaml"
+
+// Violation is a single schema validation failure.
+type Violation struct {
+	// Path is the JSON pointer of the offending value, e.g. "/persona/tone".
+	Path string
+	// Keyword is the schema keyword that failed, e.g. "maxLength".
+	Keyword string
+	// Message is the validator's human-readable description.
+	Message string
+	// Line and Column locate the offending value in the YAML source. They
+	// are zero when the document has no position for it.
+	Line   int
+	Column int
+}
+
+func (v Violation) String() string {
+	if v.Path == "" {
+		return v.Message
+	}
+	return fmt.Sprintf("%s: %s", v.Path, v.Message)
+}
+
+var (
+	compileOnce sync.Once
+	compiled    *jsonschema.Schema
+	compileErr  error
+)
+
+// manifestSchema compiles the embedded schema on first use.
+func manifestSchema() (*jsonschema.Schema, error) {
+	compileOnce.Do(func() {
+		var doc yaml.Node
+		if err := yaml.Unmarshal(spec.ManifestSchema, &doc); err != nil {
+			compileErr = fmt.Errorf("failed to parse embedded schema: %w", err)
+			return
+		}
+		raw, err := json.Marshal(toJSONValue(&doc))
+		if err != nil {
+			compileErr = fmt.Errorf("failed to encode embedded schema: %w", err)
+			return
+		}
+
+		c := jsonschema.NewCompiler()
+		c.Draft = jsonschema.Draft2020
+		c.AssertFormat = true
+		if err := c.AddResource(schemaURL, bytes.NewReader(raw)); err != nil {
+			compileErr = fmt.Errorf("failed to load embedded schema: %w", err)
+			return
+		}
+		compiled, compileErr = c.Compile(schemaURL)
+	})
+	return compiled, compileErr
+}
+
+// Validate checks raw manifest YAML against the manifest schema.
+//
+// A non-nil error means the document could not be checked at all (for example
+// invalid YAML); schema failures are reported as violations.
+func Validate(data []byte) ([]Violation, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return ValidateNode(&doc)
+}
+
+// ValidateNode checks an already decoded YAML document against the manifest schema.
+func ValidateNode(doc *yaml.Node) ([]Violation, error) {
+	s, err := manifestSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.Validate(toJSONValue(doc))
+	if err == nil {
+		return nil, nil
+	}
+	verr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return nil, err
+	}
+
+	var leaves []Violation
+	collectLeaves(verr, &leaves)
+
+	var violations []Violation
+	for _, v := range leaves {
+		violations = append(violations, locate(doc, v)...)
+	}
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].Line != violations[j].Line {
+			return violations[i].Line < violations[j].Line
+		}
+		return violations[i].Path < violations[j].Path
+	})
+	return violations, nil
+}
+
+// quotedNameRe extracts the property names the validator quotes in
+// additionalProperties messages.
+var quotedNameRe = regexp.MustCompile(`'([^']*)'`)
+
+// locate fills in the source position of a violation. An additionalProperties
+// failure names every offending key in one message; it is split so each
+// unexpected key is reported where it appears.
+func locate(doc *yaml.Node, v Violation) []Violation {
+	key, value := Locate(doc, v.Path)
+
+	if v.Keyword == "additionalProperties" && value != nil && value.Kind == yaml.MappingNode {
+		var out []Violation
+		for _, m := range quotedNameRe.FindAllStringSubmatch(v.Message, -1) {
+			name := m[1]
+			prop := v
+			prop.Message = fmt.Sprintf("property %q is not allowed", name)
+			if k, _ := Locate(doc, v.Path+"/"+escapePointer(name)); k != nil {
+				prop.Line, prop.Column = k.Line, k.Column
+			}
+			out = append(out, prop)
+		}
+		if len(out) > 0 {
+			return out
+		}
+	}
+
+	switch {
+	case value != nil:
+		v.Line, v.Column = value.Line, value.Column
+	case key != nil:
+		v.Line, v.Column = key.Line, key.Column
+	}
+	return []Violation{v}
+}
+
+// Locate resolves a JSON pointer against a YAML document. For mapping members
+// it returns both the key node and the value node; for the root and sequence
+// items key is nil. Both are nil if the pointer does not resolve.
+func Locate(doc *yaml.Node, pointer string) (key, value *yaml.Node) {
+	n := doc
+	if n.Kind == yaml.DocumentNode {
+		if len(n.Content) == 0 {
+			return nil, nil
+		}
+		n = n.Content[0]
+	}
+	if pointer == "" {
+		return nil, n
+	}
+
+	for _, tok := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		tok = unescapePointer(tok)
+		for n.Kind == yaml.AliasNode {
+			n = n.Alias
+		}
+		key = nil
+		switch n.Kind {
+		case yaml.MappingNode:
+			var next *yaml.Node
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				if n.Content[i].Value == tok {
+					key, next = n.Content[i], n.Content[i+1]
+					break
+				}
+			}
+			if next == nil {
+				return nil, nil
+			}
+			n = next
+		case yaml.SequenceNode:
+			idx, err := strconv.Atoi(tok)
+			if err != nil || idx < 0 || idx >= len(n.Content) {
+				return nil, nil
+			}
+			n = n.Content[idx]
+		default:
+			return nil, nil
+		}
+	}
+	return key, n
+}
+
+// FieldPath renders a JSON pointer the way fields are written in a manifest,
+// e.g. "/variables/0/name" becomes "variables[0].name".
+func FieldPath(pointer string) string {
+	if pointer == "" {
+		return ""
+	}
+	var b strings.Builder
+	for _, tok := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		tok = unescapePointer(tok)
+		if _, err := strconv.Atoi(tok); err == nil {
+			fmt.Fprintf(&b, "[%s]", tok)
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(tok)
+	}
+	return b.String()
+}
+
+func unescapePointer(tok string) string {
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(tok)
+}
+
+func escapePointer(tok string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(tok)
+}
+
+// collectLeaves flattens the validator's error tree into its leaf causes,
+// which are the ones that name a concrete value and keyword.
+func collectLeaves(e *jsonschema.ValidationError, out *[]Violation) {
+	if len(e.Causes) == 0 {
+		keyword := e.KeywordLocation
+		if i := strings.LastIndex(keyword, "/"); i >= 0 {
+			keyword = keyword[i+1:]
+		}
+		*out = append(*out, Violation{
+			Path:    e.InstanceLocation,
+			Keyword: keyword,
+			Message: e.Message,
+		})
+		return
+	}
+	for _, c := range e.Causes {
+		collectLeaves(c, out)
+	}
+}
+
+// toJSONValue converts a YAML node into the plain JSON value model the
+// validator expects. Scalars keep their YAML-resolved type, except timestamps
+// which stay strings since JSON has no such type.
+func toJSONValue(n *yaml.Node) interface{} {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil
+		}
+		return toJSONValue(n.Content[0])
+	case yaml.AliasNode:
+		return toJSONValue(n.Alias)
+	case yaml.MappingNode:
+		obj := make(map[string]interface{}, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			obj[n.Content[i].Value] = toJSONValue(n.Content[i+1])
+		}
+		return obj
+	case yaml.SequenceNode:
+		arr := make([]interface{}, 0, len(n.Content))
+		for _, c := range n.Content {
+			arr = append(arr, toJSONValue(c))
+		}
+		return arr
+	}
+
+	switch n.ShortTag() {
+	case "!!null":
+		return nil
+	case "!!bool":
+		var b bool
+		if err := n.Decode(&b); err == nil {
+			return b
+		}
+	case "!!int":
+		var i int64
+		if err := n.Decode(&i); err == nil {
+			return json.Number(strconv.FormatInt(i, 10))
+		}
+	case "!!float":
+		var f float64
+		if err := n.Decode(&f); err == nil {
+			return f
+		}
+	}
+	return n.Value
+}
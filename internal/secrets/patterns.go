@@ -0,0 +1,222 @@
+package secrets
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+// detector finds one kind of sensitive value in a line of text.
+type detector struct {
+	id          string
+	description string
+	re          *regexp.Regexp
+	// group selects the submatch holding the sensitive value; 0 is the
+	// whole match.
+	group int
+	// verify rejects matches the regexp alone cannot rule out.
+	verify func(match string) bool
+}
+
+// detectors are checked in order; an earlier detector wins when two match
+// overlapping text on the same line.
+var detectors = []detector{
+	// Credentials
+	{
+		id:          "private-key",
+		description: "PEM-encoded private key",
+		re:          regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----`),
+	},
+	{
+		id:          "aws-access-key",
+		description: "AWS access key ID",
+		re:          regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`),
+	},
+	{
+		id:          "aws-secret-key",
+		description: "AWS secret access key",
+		re:          regexp.MustCompile(`(?i)aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`),
+		group:       1,
+	},
+	{
+		id:          "github-token",
+		description: "GitHub token",
+		re:          regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`),
+	},
+	{
+		id:          "slack-token",
+		description: "Slack token",
+		re:          regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`),
+	},
+	{
+		id:          "anthropic-api-key",
+		description: "Anthropic API key",
+		re:          regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`),
+	},
+	{
+		id:          "openai-api-key",
+		description: "OpenAI API key",
+		re:          regexp.MustCompile(`\bsk-(?:proj-|svcacct-)?[A-Za-z0-9_-]{20,}`),
+	},
+	{
+		id:          "stripe-key",
+		description: "Stripe secret key",
+		re:          regexp.MustCompile(`\b(?:sk|rk)_live_[A-Za-z0-9]{20,}\b`),
+	},
+	{
+		id:          "google-api-key",
+		description: "Google API key",
+		re:          regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+	},
+	{
+		id:          "jwt",
+		description: "JSON Web Token",
+		re:          regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`),
+	},
+	{
+		id:          "generic-secret",
+		description: "credential assigned to a key, password or token field",
+		re:          regexp.MustCompile(`(?i)\b(?:api[_-]?key|secret|passw(?:or)?d|access[_-]?token|auth[_-]?token|client[_-]?secret)["']?\s*[:=]\s*["']?([^\s"']{8,})`),
+		group:       1,
+		verify:      func(s string) bool { return !isPlaceholder(s) },
+	},
+
+	// Personal and internal data
+	{
+		id:          "email",
+		description: "email address",
+		re:          regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`),
+		verify:      func(s string) bool { return !isExampleDomain(s[strings.LastIndex(s, "@")+1:]) },
+	},
+	{
+		id:          "credit-card",
+		description: "payment card number",
+		re:          regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		verify:      luhnValid,
+	},
+	{
+		id:          "us-ssn",
+		description: "US social security number",
+		re:          regexp.MustCompile(`\b(?:00[1-9]|0[1-9]\d|[1-578]\d\d|6[0-57-9]\d|66[0-57-9])-(?:0[1-9]|[1-9]\d)-(?:\d{3}[1-9]|\d{2}[1-9]\d|\d[1-9]\d{2}|[1-9]\d{3})\b`),
+	},
+	{
+		id:          "phone-number",
+		description: "phone number",
+		re:          regexp.MustCompile(`(?:^|[^\w+])(\+\d{1,3}[ .-]?\(?\d{2,4}\)?[ .-]?\d{3,4}[ .-]?\d{3,4})\b`),
+		group:       1,
+	},
+	{
+		id:          "private-ip",
+		description: "private network address",
+		re:          regexp.MustCompile(`\b(?:10\.\d{1,3}\.\d{1,3}\.\d{1,3}|192\.168\.\d{1,3}\.\d{1,3}|172\.(?:1[6-9]|2\d|3[01])\.\d{1,3}\.\d{1,3})\b`),
+	},
+	{
+		id:          "internal-hostname",
+		description: "internal hostname",
+		re:          regexp.MustCompile(`(?i)\b[a-z0-9](?:[a-z0-9-]*[a-z0-9])?(?:\.[a-z0-9](?:[a-z0-9-]*[a-z0-9])?)*\.(?:internal|corp|intranet|lan|local|localdomain)\b`),
+	},
+}
+
+// highEntropyID is reported for random-looking strings no specific
+// detector recognized.
+const highEntropyID = "high-entropy-string"
+
+var entropyCandidateRe = regexp.MustCompile(`[A-Za-z0-9+/_=-]{24,}`)
+
+// minEntropy is the Shannon entropy, in bits per character, above which a
+// candidate token is considered random. English words and identifiers sit
+// well below it; base64 and hex secrets sit above.
+const minEntropy = 4.0
+
+// looksRandom reports whether s is likely a generated secret rather than
+// prose or an identifier.
+func looksRandom(s string) bool {
+	var hasDigit, hasLetter bool
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			hasDigit = true
+		case (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+			hasLetter = true
+		}
+	}
+	if !hasDigit || !hasLetter {
+		return false
+	}
+	return shannonEntropy(s) >= minEntropy
+}
+
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// exampleDomains are reserved for documentation and never belong to a
+// real person.
+var exampleDomains = []string{"example.com", "example.org", "example.net", "test", "invalid", "localhost"}
+
+func isExampleDomain(domain string) bool {
+	domain = strings.ToLower(domain)
+	for _, d := range exampleDomains {
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPlaceholder recognizes template values such as "{{api_key}}",
+// "<token>", "${SECRET}" or "changeme" that are safe to publish.
+func isPlaceholder(s string) bool {
+	lower := strings.ToLower(s)
+	if strings.ContainsAny(s, "{}<>$") {
+		return true
+	}
+	for _, p := range []string{"changeme", "example", "placeholder", "redacted", "xxxx", "your_", "your-", "dummy"} {
+		if strings.Contains(lower, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// luhnValid checks a candidate card number's checksum, which rules out most
+// long digit runs that are not card numbers.
+func luhnValid(s string) bool {
+	var digits []int
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, int(r-'0'))
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := digits[i]
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
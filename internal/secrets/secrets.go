@@ -0,0 +1,230 @@
+// Package secrets detects credentials, high-entropy strings and personal
+// data in prompt packages before they are built or published.
+package secrets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/promptbucket/cli/internal/diag"
+	"gopkg.in/yaml.v3"
+)
+
+// AllowlistFile is the allowlist looked up next to the manifest.
+const AllowlistFile = ".promptbucket-secrets.yaml"
+
+// Finding is a sensitive value found in a file.
+type Finding struct {
+	File   string
+	Line   int
+	Column int
+	Rule   string
+	// Description names what was found, e.g. "AWS access key ID".
+	Description string
+	// Match is the sensitive value itself. It is never printed; use Redacted.
+	Match string
+	// Fingerprint identifies this value for allowlisting without repeating it.
+	Fingerprint string
+}
+
+// Redacted returns the match with all but its edges masked.
+func (f Finding) Redacted() string {
+	r := []rune(f.Match)
+	if len(r) <= 8 {
+		return strings.Repeat("*", len(r))
+	}
+	return string(r[:4]) + strings.Repeat("*", len(r)-6) + string(r[len(r)-2:])
+}
+
+// Diagnostic converts the finding for reporting alongside other checks.
+func (f Finding) Diagnostic() diag.Diagnostic {
+	return diag.Diagnostic{
+		File:     f.File,
+		Line:     f.Line,
+		Column:   f.Column,
+		Rule:     "secrets/" + f.Rule,
+		Severity: diag.SeverityError,
+		Message:  fmt.Sprintf("possible %s %q (fingerprint %s)", f.Description, f.Redacted(), f.Fingerprint),
+	}
+}
+
+// Fingerprint returns the allowlist fingerprint for a rule and value.
+func Fingerprint(rule, match string) string {
+	sum := sha256.Sum256([]byte(rule + ":" + match))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Allowlist suppresses findings that are known to be safe to publish.
+//
+//	allowlist:
+//	  - fingerprint: 3f9a1c0de2b47a51   # one specific value
+//	    reason: public support address
+//	  - rule: email
+//	    match: '@acme\.com$'            # regexp over the matched value
+//	  - path: 'fixtures/*'              # glob over the file path
+//
+// Every field given in an entry must match for it to apply.
+type Allowlist struct {
+	Entries []AllowEntry `yaml:"allowlist"`
+}
+
+// AllowEntry is a single allowlist rule.
+type AllowEntry struct {
+	Fingerprint string `yaml:"fingerprint,omitempty"`
+	Rule        string `yaml:"rule,omitempty"`
+	Match       string `yaml:"match,omitempty"`
+	Path        string `yaml:"path,omitempty"`
+	Reason      string `yaml:"reason,omitempty"`
+
+	matchRe *regexp.Regexp
+}
+
+// LoadAllowlist reads an allowlist file.
+func LoadAllowlist(path string) (*Allowlist, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets allowlist %s: %w", path, err)
+	}
+
+	var al Allowlist
+	if err := yaml.Unmarshal(data, &al); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets allowlist %s: %w", path, err)
+	}
+	for i := range al.Entries {
+		e := &al.Entries[i]
+		if e.Fingerprint == "" && e.Rule == "" && e.Match == "" && e.Path == "" {
+			return nil, fmt.Errorf("secrets allowlist %s: entry %d matches everything", path, i+1)
+		}
+		if e.Match != "" {
+			if e.matchRe, err = regexp.Compile(e.Match); err != nil {
+				return nil, fmt.Errorf("secrets allowlist %s: entry %d: invalid match pattern: %w", path, i+1, err)
+			}
+		}
+	}
+	return &al, nil
+}
+
+// FindAllowlist returns the allowlist that applies to a manifest: the one in
+// the manifest's directory, else the one in the working directory. It returns
+// an empty path if neither exists.
+func FindAllowlist(manifestPath string) string {
+	candidates := []string{
+		filepath.Join(filepath.Dir(manifestPath), AllowlistFile),
+		AllowlistFile,
+	}
+	for _, c := range candidates {
+		if _, err := os.Stat(c); err == nil {
+			return c
+		}
+	}
+	return ""
+}
+
+// Allows reports whether the finding is allowlisted.
+func (al *Allowlist) Allows(f Finding) bool {
+	if al == nil {
+		return false
+	}
+	for _, e := range al.Entries {
+		if e.Fingerprint != "" && e.Fingerprint != f.Fingerprint {
+			continue
+		}
+		if e.Rule != "" && e.Rule != f.Rule {
+			continue
+		}
+		if e.matchRe != nil && !e.matchRe.MatchString(f.Match) {
+			continue
+		}
+		if e.Path != "" {
+			if ok, _ := filepath.Match(e.Path, filepath.ToSlash(f.File)); !ok {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// ScanFiles scans each file and returns the findings not covered by the
+// allowlist, which may be nil.
+func ScanFiles(paths []string, al *Allowlist) ([]Finding, error) {
+	var findings []Finding
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		for _, f := range Scan(p, data) {
+			if !al.Allows(f) {
+				findings = append(findings, f)
+			}
+		}
+	}
+	return findings, nil
+}
+
+// Scan returns every sensitive value found in data, which was read from path.
+func Scan(path string, data []byte) []Finding {
+	var findings []Finding
+	for i, line := range strings.Split(string(data), "\n") {
+		var taken [][2]int
+		overlaps := func(start, end int) bool {
+			for _, t := range taken {
+				if start < t[1] && t[0] < end {
+					return true
+				}
+			}
+			return false
+		}
+		add := func(rule, desc string, start, end int) {
+			taken = append(taken, [2]int{start, end})
+			match := line[start:end]
+			findings = append(findings, Finding{
+				File:        path,
+				Line:        i + 1,
+				Column:      len([]rune(line[:start])) + 1,
+				Rule:        rule,
+				Description: desc,
+				Match:       match,
+				Fingerprint: Fingerprint(rule, match),
+			})
+		}
+
+		for _, d := range detectors {
+			for _, loc := range d.re.FindAllStringSubmatchIndex(line, -1) {
+				start, end := loc[2*d.group], loc[2*d.group+1]
+				if start < 0 || overlaps(start, end) {
+					continue
+				}
+				if d.verify != nil && !d.verify(line[start:end]) {
+					continue
+				}
+				add(d.id, d.description, start, end)
+			}
+		}
+
+		for _, loc := range entropyCandidateRe.FindAllStringIndex(line, -1) {
+			start, end := loc[0], loc[1]
+			if overlaps(start, end) || strings.HasSuffix(line[:start], "sha256:") {
+				continue
+			}
+			if looksRandom(line[start:end]) {
+				add(highEntropyID, "high-entropy secret", start, end)
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Line != findings[j].Line {
+			return findings[i].Line < findings[j].Line
+		}
+		return findings[i].Column < findings[j].Column
+	})
+	return findings
+}
@@ -0,0 +1,244 @@
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Range is a set of versions, written in the npm style:
+//
+//	1.2.3           exactly 1.2.3
+//	^1.2            >=1.2.0 <2.0.0 (for 0.x, the minor version is fixed)
+//	~1.2.3          >=1.2.3 <1.3.0
+//	1.x, 1.2.*      any version with that prefix
+//	>=1.0 <2        both comparators must hold
+//	^1 || ^2        either side may hold
+//
+// Pre-releases only satisfy a range that names a pre-release of the same
+// major.minor.patch, so ^1.2 never resolves to 1.3.0-beta.
+type Range struct {
+	raw  string
+	sets [][]comparator
+}
+
+type comparator struct {
+	op string // one of =, <, <=, >, >=
+	v  Version
+}
+
+// partialRe matches a version with optional minor and patch, where missing
+// or wildcard parts are "x", "X" or "*".
+var partialRe = regexp.MustCompile(`^v?(\d+|[xX*])(?:\.(\d+|[xX*]))?(?:\.(\d+|[xX*]))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+var opRe = regexp.MustCompile(`^(<=|>=|<|>|=|\^|~)?\s*(.*)$`)
+
+// ParseRange parses a range expression.
+func ParseRange(s string) (Range, error) {
+	r := Range{raw: strings.TrimSpace(s)}
+	if r.raw == "" {
+		return Range{}, fmt.Errorf("empty version range")
+	}
+
+	for _, alt := range strings.Split(r.raw, "||") {
+		var set []comparator
+		fields := strings.Fields(alt)
+		if len(fields) == 0 {
+			return Range{}, fmt.Errorf("invalid version range %q: empty alternative", s)
+		}
+		for i := 0; i < len(fields); i++ {
+			term := fields[i]
+			// Allow a space between an operator and its version, as in ">= 1.0"
+			if strings.Trim(term, "<>=^~") == "" && i+1 < len(fields) {
+				term += fields[i+1]
+				i++
+			}
+			cs, err := parseTerm(term)
+			if err != nil {
+				return Range{}, fmt.Errorf("invalid version range %q: %w", s, err)
+			}
+			set = append(set, cs...)
+		}
+		r.sets = append(r.sets, set)
+	}
+	return r, nil
+}
+
+// parseTerm expands one operator and (partial) version into comparators.
+func parseTerm(term string) ([]comparator, error) {
+	m := opRe.FindStringSubmatch(term)
+	op, rest := m[1], m[2]
+	p := partialRe.FindStringSubmatch(rest)
+	if p == nil {
+		return nil, fmt.Errorf("invalid version %q", rest)
+	}
+
+	// Count the leading numeric parts; wildcards and omissions end them
+	var nums []uint64
+	for _, part := range p[1:4] {
+		if part == "" || part == "x" || part == "X" || part == "*" {
+			break
+		}
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q", rest)
+		}
+		nums = append(nums, n)
+	}
+	var pre []string
+	if p[4] != "" {
+		if len(nums) < 3 {
+			return nil, fmt.Errorf("pre-release %q needs a full version", rest)
+		}
+		pre = strings.Split(p[4], ".")
+	}
+
+	lower := Version{Prerelease: pre}
+	for i, n := range nums {
+		switch i {
+		case 0:
+			lower.Major = n
+		case 1:
+			lower.Minor = n
+		case 2:
+			lower.Patch = n
+		}
+	}
+
+	// bump returns the first version past the given number of fixed parts
+	bump := func(fixed int) Version {
+		switch fixed {
+		case 1:
+			return Version{Major: lower.Major + 1, Prerelease: []string{"0"}}
+		case 2:
+			return Version{Major: lower.Major, Minor: lower.Minor + 1, Prerelease: []string{"0"}}
+		}
+		return Version{Major: lower.Major, Minor: lower.Minor, Patch: lower.Patch + 1, Prerelease: []string{"0"}}
+	}
+	between := func(hi Version) []comparator {
+		return []comparator{{">=", lower}, {"<", hi}}
+	}
+
+	switch op {
+	case "^":
+		if len(nums) == 0 {
+			return nil, nil
+		}
+		// The left-most non-zero part is fixed
+		switch {
+		case lower.Major > 0 || len(nums) == 1:
+			return between(bump(1)), nil
+		case lower.Minor > 0 || len(nums) == 2:
+			return between(bump(2)), nil
+		}
+		return between(bump(3)), nil
+	case "~":
+		if len(nums) == 0 {
+			return nil, nil
+		}
+		if len(nums) == 1 {
+			return between(bump(1)), nil
+		}
+		return between(bump(2)), nil
+	case "", "=":
+		if len(nums) == 0 {
+			return nil, nil
+		}
+		if len(nums) < 3 {
+			return between(bump(len(nums))), nil
+		}
+		return []comparator{{"=", lower}}, nil
+	case ">=":
+		return []comparator{{">=", lower}}, nil
+	case ">":
+		if len(nums) < 3 && len(nums) > 0 {
+			// >1.2 means past every 1.2.x
+			return []comparator{{">=", bump(len(nums))}}, nil
+		}
+		return []comparator{{">", lower}}, nil
+	case "<":
+		return []comparator{{"<", withFloor(lower, len(nums))}}, nil
+	case "<=":
+		if len(nums) < 3 && len(nums) > 0 {
+			// <=1.2 includes every 1.2.x
+			return []comparator{{"<", bump(len(nums))}}, nil
+		}
+		return []comparator{{"<=", lower}}, nil
+	}
+	return nil, fmt.Errorf("invalid operator %q", op)
+}
+
+// withFloor makes a partial upper bound exclude pre-releases of its floor,
+// so "<2" excludes 2.0.0-beta.
+func withFloor(v Version, parts int) Version {
+	if parts < 3 && len(v.Prerelease) == 0 {
+		v.Prerelease = []string{"0"}
+	}
+	return v
+}
+
+func (c comparator) matches(v Version) bool {
+	cmp := v.Compare(c.v)
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// Contains reports whether v is in the range.
+func (r Range) Contains(v Version) bool {
+	for _, set := range r.sets {
+		if setContains(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func setContains(set []comparator, v Version) bool {
+	for _, c := range set {
+		if !c.matches(v) {
+			return false
+		}
+	}
+	if !v.IsPrerelease() {
+		return true
+	}
+
+	// A pre-release needs a comparator naming a pre-release of the same
+	// release; synthetic "-0" bounds do not count.
+	for _, c := range set {
+		if c.v.IsPrerelease() && !(len(c.v.Prerelease) == 1 && c.v.Prerelease[0] == "0") &&
+			c.v.Major == v.Major && c.v.Minor == v.Minor && c.v.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the range as written.
+func (r Range) String() string {
+	return r.raw
+}
+
+// MaxSatisfying returns the highest version in the range, or false if none
+// is.
+func (r Range) MaxSatisfying(versions []Version) (Version, bool) {
+	var in []Version
+	for _, v := range versions {
+		if r.Contains(v) {
+			in = append(in, v)
+		}
+	}
+	return Max(in)
+}
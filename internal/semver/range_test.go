@@ -0,0 +1,124 @@
+package semver
+
+import "testing"
+
+func TestRangeContains(t *testing.T) {
+	tests := []struct {
+		rng     string
+		version string
+		want    bool
+	}{
+		{"1.2.3", "1.2.3", true},
+		{"1.2.3", "1.2.4", false},
+		{"=1.2.3", "1.2.3", true},
+		{"v1.2.3", "1.2.3", true},
+
+		{"^1.2", "1.2.0", true},
+		{"^1.2", "1.9.9", true},
+		{"^1.2", "2.0.0", false},
+		{"^1.2", "1.1.9", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.0.3", "0.0.3", true},
+		{"^0.0.3", "0.0.4", false},
+		{"^0", "0.9.0", true},
+		{"^0", "1.0.0", false},
+
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{"~1", "1.9.0", true},
+		{"~1", "2.0.0", false},
+
+		{"1.x", "1.5.0", true},
+		{"1.x", "2.0.0", false},
+		{"1.2.*", "1.2.7", true},
+		{"1.2.*", "1.3.0", false},
+		{"*", "4.5.6", true},
+		{"1", "1.9.9", true},
+
+		{">=1.0 <2", "1.5.0", true},
+		{">=1.0 <2", "2.0.0", false},
+		{">= 1.0", "1.0.0", true},
+		{">1.2", "1.2.9", false},
+		{">1.2", "1.3.0", true},
+		{">1.2.3", "1.2.4", true},
+		{"<=1.2", "1.2.9", true},
+		{"<=1.2", "1.3.0", false},
+		{"<=1.2.3", "1.2.3", true},
+
+		{"^1 || ^3", "1.4.0", true},
+		{"^1 || ^3", "2.0.0", false},
+		{"^1 || ^3", "3.1.0", true},
+
+		// Pre-releases only match a range naming one of the same release
+		{"^1.2", "1.3.0-beta", false},
+		{"<2", "2.0.0-beta", false},
+		{"*", "1.0.0-rc.1", false},
+		{"^1.2.3-beta.2", "1.2.3-beta.3", true},
+		{"^1.2.3-beta.2", "1.2.3-beta.1", false},
+		{"^1.2.3-beta.2", "1.2.4-beta.1", false},
+		{"^1.2.3-beta.2", "1.2.5", true},
+		{">=2.0.0-rc.1", "2.0.0-rc.2", true},
+		{">=2.0.0-rc.1", "2.0.0", true},
+	}
+
+	for _, tt := range tests {
+		r, err := ParseRange(tt.rng)
+		if err != nil {
+			t.Errorf("ParseRange(%q): %v", tt.rng, err)
+			continue
+		}
+		if got := r.Contains(mustParse(t, tt.version)); got != tt.want {
+			t.Errorf("ParseRange(%q).Contains(%s) = %v, want %v", tt.rng, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestParseRangeErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"  ",
+		"^1 ||",
+		"|| ^1",
+		"banana",
+		"1.2.3.4",
+		"1.2-beta",
+		">>1.0",
+		"^1.0 <",
+	} {
+		if r, err := ParseRange(s); err == nil {
+			t.Errorf("ParseRange(%q) = %v, want an error", s, r)
+		}
+	}
+}
+
+func TestMaxSatisfying(t *testing.T) {
+	var versions []Version
+	for _, s := range []string{"1.0.0", "1.4.2", "1.5.0-beta.1", "2.0.0", "2.1.0-rc.1"} {
+		versions = append(versions, mustParse(t, s))
+	}
+
+	tests := []struct {
+		rng  string
+		want string
+		ok   bool
+	}{
+		{"^1", "1.4.2", true},
+		{"~1.0", "1.0.0", true},
+		{">=1", "2.0.0", true},
+		{"^1.5.0-beta.0", "1.5.0-beta.1", true},
+		{"^2.1.0-rc.0", "2.1.0-rc.1", true},
+		{"^3", "", false},
+	}
+
+	for _, tt := range tests {
+		r, err := ParseRange(tt.rng)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := r.MaxSatisfying(versions)
+		if ok != tt.ok || (ok && got.String() != tt.want) {
+			t.Errorf("ParseRange(%q).MaxSatisfying() = %s, %v; want %s, %v", tt.rng, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Package semver parses and orders package versions following Semantic
+// Versioning 2.0.0 (https://semver.org), including pre-release and build
+// metadata.
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Pattern is the semver.org regular expression for a full version. The
+// manifest schema uses the same expression.
+const Pattern = `^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+	`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+	`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`
+
+var versionRe = regexp.MustCompile(Pattern)
+
+// Version is a parsed semantic version.
+type Version struct {
+	Major, Minor, Patch uint64
+	// Prerelease holds the dot-separated identifiers after "-", e.g.
+	// ["rc", "1"] for 1.0.0-rc.1.
+	Prerelease []string
+	// Build is the metadata after "+". It does not affect ordering.
+	Build string
+}
+
+// Parse parses a version such as "1.4.0", "2.0.0-beta.2" or "1.0.0+20240101".
+func Parse(s string) (Version, error) {
+	m := versionRe.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf("invalid semantic version %q", s)
+	}
+
+	var v Version
+	var err error
+	if v.Major, err = strconv.ParseUint(m[1], 10, 64); err != nil {
+		return Version{}, fmt.Errorf("invalid semantic version %q: %w", s, err)
+	}
+	if v.Minor, err = strconv.ParseUint(m[2], 10, 64); err != nil {
+		return Version{}, fmt.Errorf("invalid semantic version %q: %w", s, err)
+	}
+	if v.Patch, err = strconv.ParseUint(m[3], 10, 64); err != nil {
+		return Version{}, fmt.Errorf("invalid semantic version %q: %w", s, err)
+	}
+	if m[4] != "" {
+		v.Prerelease = strings.Split(m[4], ".")
+	}
+	v.Build = m[5]
+	return v, nil
+}
+
+// String formats the version in canonical form.
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// IsPrerelease reports whether v has pre-release identifiers.
+func (v Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// Compare returns -1, 0 or 1 as v is lower than, equal to or higher than o in
+// semver precedence. Build metadata is ignored, so 1.0.0+a and 1.0.0+b are
+// equal.
+func (v Version) Compare(o Version) int {
+	if c := compareUint(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+
+	// A release is higher than any of its pre-releases
+	switch {
+	case len(v.Prerelease) == 0 && len(o.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(o.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(o.Prerelease); i++ {
+		if c := compareIdentifier(v.Prerelease[i], o.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return compareUint(uint64(len(v.Prerelease)), uint64(len(o.Prerelease)))
+}
+
+// Less reports whether v has lower precedence than o.
+func (v Version) Less(o Version) bool {
+	return v.Compare(o) < 0
+}
+
+// compareIdentifier orders pre-release identifiers: numeric ones compare
+// numerically and sort before alphanumeric ones, which compare in ASCII order.
+func compareIdentifier(a, b string) int {
+	an, aErr := strconv.ParseUint(a, 10, 64)
+	bn, bErr := strconv.ParseUint(b, 10, 64)
+	switch {
+	case aErr == nil && bErr == nil:
+		return compareUint(an, bn)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Max returns the highest of the given versions, or false if there are none.
+func Max(versions []Version) (Version, bool) {
+	if len(versions) == 0 {
+		return Version{}, false
+	}
+	max := versions[0]
+	for _, v := range versions[1:] {
+		if max.Less(v) {
+			max = v
+		}
+	}
+	return max, true
+}
@@ -0,0 +1,140 @@
+package semver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Version
+		wantErr bool
+	}{
+		{in: "1.4.0", want: Version{Major: 1, Minor: 4}},
+		{in: "0.0.0", want: Version{}},
+		{in: "2.0.0-beta.2", want: Version{Major: 2, Prerelease: []string{"beta", "2"}}},
+		{in: "1.0.0-0.3.7", want: Version{Major: 1, Prerelease: []string{"0", "3", "7"}}},
+		{in: "1.0.0-x-y.1", want: Version{Major: 1, Prerelease: []string{"x-y", "1"}}},
+		{in: "1.0.0+20240101", want: Version{Major: 1, Build: "20240101"}},
+		{in: "1.0.0-rc.1+exp.sha.5114f85", want: Version{Major: 1, Prerelease: []string{"rc", "1"}, Build: "exp.sha.5114f85"}},
+		{in: "", wantErr: true},
+		{in: "1", wantErr: true},
+		{in: "1.2", wantErr: true},
+		{in: "v1.2.3", wantErr: true},
+		{in: "01.2.3", wantErr: true},
+		{in: "1.02.3", wantErr: true},
+		{in: "1.2.3-01", wantErr: true},
+		{in: "1.2.3-", wantErr: true},
+		{in: "1.2.3-beta..1", wantErr: true},
+		{in: "1.2.3+", wantErr: true},
+		{in: "1.2.3 ", wantErr: true},
+		{in: "99999999999999999999.0.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %#v, want %#v", tt.in, got, tt.want)
+		}
+		if s := got.String(); s != tt.in {
+			t.Errorf("Parse(%q).String() = %q", tt.in, s)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.0.0", "2.0.0", -1},
+		{"2.0.0", "1.9.9", 1},
+		{"1.2.0", "1.10.0", -1},
+		{"1.0.9", "1.0.10", -1},
+		{"1.0.0-alpha", "1.0.0", -1},
+		{"1.0.0", "1.0.0-rc.1", 1},
+		{"1.0.0+a", "1.0.0+b", 0},
+		{"1.0.0-rc.1+a", "1.0.0-rc.1", 0},
+		{"1.0.0-2", "1.0.0-10", -1},
+		{"1.0.0-1", "1.0.0-alpha", -1},
+		{"1.0.0-beta", "1.0.0-alpha", 1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"0.9.0", "1.0.0-alpha", -1},
+	}
+
+	for _, tt := range tests {
+		a, b := mustParse(t, tt.a), mustParse(t, tt.b)
+		if got := a.Compare(b); got != tt.want {
+			t.Errorf("Compare(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := b.Compare(a); got != -tt.want {
+			t.Errorf("Compare(%s, %s) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+// TestPrereleaseOrdering checks the precedence example from semver.org.
+func TestPrereleaseOrdering(t *testing.T) {
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+	}
+	for i := 1; i < len(ordered); i++ {
+		lo, hi := mustParse(t, ordered[i-1]), mustParse(t, ordered[i])
+		if !lo.Less(hi) || hi.Less(lo) {
+			t.Errorf("want %s < %s", lo, hi)
+		}
+	}
+}
+
+func TestMax(t *testing.T) {
+	tests := []struct {
+		in   []string
+		want string
+		ok   bool
+	}{
+		{in: nil, ok: false},
+		{in: []string{"1.0.0"}, want: "1.0.0", ok: true},
+		{in: []string{"1.0.0", "1.10.0", "1.9.0"}, want: "1.10.0", ok: true},
+		{in: []string{"2.0.0-rc.1", "1.5.0"}, want: "2.0.0-rc.1", ok: true},
+		{in: []string{"1.0.0-rc.1", "1.0.0"}, want: "1.0.0", ok: true},
+	}
+
+	for _, tt := range tests {
+		var versions []Version
+		for _, s := range tt.in {
+			versions = append(versions, mustParse(t, s))
+		}
+		got, ok := Max(versions)
+		if ok != tt.ok || (ok && got.String() != tt.want) {
+			t.Errorf("Max(%v) = %s, %v; want %s, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func mustParse(t *testing.T, s string) Version {
+	t.Helper()
+	v, err := Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
@@ -0,0 +1,150 @@
+// Package tokens counts prompt tokens offline using tokenizers embedded in
+// the binary.
+//
+// OpenAI's published BPE encodings are embedded and counted exactly. Other
+// model families do not publish their tokenizers, so their counts are
+// estimated from the closest public encoding with a per-family correction
+// factor and are reported as estimates.
+package tokens
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+func init() {
+	// Never download encodings; the offline loader reads embedded copies.
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+// Family is a group of models sharing a tokenizer.
+type Family struct {
+	// Name identifies the family on the command line, e.g. "openai-o200k".
+	Name string
+	// Encoding is the embedded BPE encoding used for counting.
+	Encoding string
+	// Exact is true when Encoding is the family's real tokenizer.
+	Exact bool
+	// Scale corrects estimated counts for families without a public
+	// tokenizer. It is 1 for exact families.
+	Scale float64
+	// prefixes are the model_hint prefixes that select this family.
+	prefixes []string
+}
+
+// DefaultFamily is used when a manifest has no model_hint or an
+// unrecognized one.
+const DefaultFamily = "openai-cl100k"
+
+var families = []Family{
+	{
+		Name: "openai-o200k", Encoding: "o200k_base", Exact: true, Scale: 1,
+		prefixes: []string{"gpt-4o", "gpt-4.1", "gpt-4.5", "gpt-5", "o1", "o3", "o4", "chatgpt-4o"},
+	},
+	{
+		Name: "openai-cl100k", Encoding: "cl100k_base", Exact: true, Scale: 1,
+		prefixes: []string{"gpt-4", "gpt-3.5", "text-embedding-3", "text-embedding-ada-002"},
+	},
+	{
+		Name: "anthropic", Encoding: "cl100k_base", Scale: 1.10,
+		prefixes: []string{"claude"},
+	},
+	{
+		Name: "gemini", Encoding: "o200k_base", Scale: 1.05,
+		prefixes: []string{"gemini", "gemma"},
+	},
+	{
+		Name: "llama", Encoding: "cl100k_base", Scale: 1.0,
+		prefixes: []string{"llama", "meta-llama", "codellama"},
+	},
+	{
+		Name: "mistral", Encoding: "cl100k_base", Scale: 1.15,
+		prefixes: []string{"mistral", "mixtral", "codestral", "ministral"},
+	},
+}
+
+// Families returns the supported tokenizer families.
+func Families() []Family {
+	return append([]Family(nil), families...)
+}
+
+// Lookup returns the family with the given name.
+func Lookup(name string) (Family, bool) {
+	for _, f := range families {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Family{}, false
+}
+
+// ForModel picks the family for a model_hint by its longest matching prefix.
+// The second result is false when the hint was empty or unrecognized and the
+// default family was returned.
+func ForModel(hint string) (Family, bool) {
+	hint = strings.ToLower(strings.TrimSpace(hint))
+	best, bestLen := Family{}, 0
+	for _, f := range families {
+		for _, p := range f.prefixes {
+			if strings.HasPrefix(hint, p) && len(p) > bestLen {
+				best, bestLen = f, len(p)
+			}
+		}
+	}
+	if bestLen == 0 {
+		def, _ := Lookup(DefaultFamily)
+		return def, false
+	}
+	return best, true
+}
+
+// Names lists family names in sorted order, for help text.
+func Names() []string {
+	names := make([]string, 0, len(families))
+	for _, f := range families {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Count returns the number of tokens text encodes to in this family.
+func (f Family) Count(text string) (int, error) {
+	enc, err := encoding(f.Encoding)
+	if err != nil {
+		return 0, err
+	}
+	n := len(enc.EncodeOrdinary(text))
+	if f.Exact || f.Scale == 1 {
+		return n, nil
+	}
+	return int(math.Ceil(float64(n) * f.Scale)), nil
+}
+
+var (
+	encodingsMu sync.Mutex
+	encodings   = map[string]*tiktoken.Tiktoken{}
+)
+
+// encoding loads an embedded encoding once; building the BPE tables is
+// expensive.
+func encoding(name string) (*tiktoken.Tiktoken, error) {
+	encodingsMu.Lock()
+	defer encodingsMu.Unlock()
+
+	if enc, ok := encodings[name]; ok {
+		return enc, nil
+	}
+	enc, err := tiktoken.GetEncoding(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tokenizer %s: %w", name, err)
+	}
+	encodings[name] = enc
+	return enc, nil
+}
@@ -0,0 +1,7 @@
+// Package version holds the CLI version, set at release time with
+//
+//	-ldflags "-X github.com/promptbucket/cli/internal/version.Version=1.2.3"
+package version
+
+// Version is the CLI version, or "dev" for local builds.
+var Version = "dev"
@@ -0,0 +1,11 @@
+// Package spec embeds the published PromptBucket specification files so the
+// CLI validates against exactly the schema that ships in this directory.
+package spec
+
+import _ "embed"
+
+// ManifestSchema is the JSON Schema (draft 2020-12, YAML encoded) for
+// promptbucket.yaml manifests.
+//
+//go:embed manifest.schema.yaml
+var ManifestSchema []byte